@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+// StreamResult is a message of a stream opened by [Stream]: a result, or the error ending the stream.
+type StreamResult[R any] struct {
+	Result R
+	Err    error
+}
+
+// Call invokes a custom JSON-RPC method of the server, such as an extension method, and decodes
+// its result into R. A JSON-RPC error is returned as *[protocol.JsonRpcError].
+// It is a function since Go methods cannot be generic.
+func Call[R any](ctx context.Context, a *A2AClient, method protocol.A2AMethod, params any) (R, error) {
+	var ret R
+
+	ch, err := a.sendRequest(ctx, method, params, false)
+	if err != nil {
+		return ret, err
+	}
+
+	raw := <-ch
+	if raw.Error != nil {
+		return ret, raw.Error
+	}
+
+	if err := json.Unmarshal(raw.Result, &ret); err != nil {
+		return ret, fmt.Errorf("unmarshal result error: %w", err)
+	}
+
+	return ret, nil
+}
+
+// Stream invokes a custom streaming JSON-RPC method of the server and decodes each message into R.
+// The channel is closed when the stream ends, a JSON-RPC error ending the stream is its last message.
+func Stream[R any](ctx context.Context, a *A2AClient, method protocol.A2AMethod, params any) (<-chan StreamResult[R], error) {
+	ch, err := a.sendRequest(ctx, method, params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(chan StreamResult[R], 10)
+	go func() {
+		defer close(ret)
+
+		// drain, so the reader of the stream can end.
+		defer func() {
+			for range ch {
+			}
+		}()
+
+		for raw := range ch {
+			var msg StreamResult[R]
+			if raw.Error != nil {
+				msg.Err = raw.Error
+			} else if err := json.Unmarshal(raw.Result, &msg.Result); err != nil {
+				msg.Err = fmt.Errorf("unmarshal result error: %w", err)
+			}
+
+			select {
+			case ret <- msg:
+			case <-ctx.Done():
+				return
+			}
+
+			if raw.Error != nil {
+				return
+			}
+		}
+	}()
+
+	return ret, nil
+}
@@ -10,9 +10,13 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/zhengrenjie/go-a2a/internal/jws"
 	"github.com/zhengrenjie/go-a2a/protocol"
 )
 
@@ -21,12 +25,29 @@ var (
 	ErrBadRequest = errors.New("build http request error")
 )
 
+const (
+	// DefaultCardTTL is how long an agent card served without Cache-Control is cached.
+	DefaultCardTTL = 5 * time.Minute
+
+	// CardFailureTTL is how long a failure to fetch the agent card is cached.
+	CardFailureTTL = 5 * time.Second
+)
+
 type (
 	A2AClient struct {
 		endpoint  url.URL
 		header    map[string]string
 		requestId atomic.Int64
 		client    *http.Client
+
+		// the agent card and its validators, cardExpires is zero for a card set by WithAgentCard.
+		cardMu         sync.Mutex
+		card           *protocol.AgentCard
+		cardETag       string
+		cardExpires    time.Time
+		cardErr        error
+		cardErrExpires time.Time
+		cardVerifier   KeyFunc
 	}
 
 	JsonRpcRaw struct {
@@ -38,9 +59,181 @@ type (
 	}
 )
 
+// NewA2AClient returns a client of the A2A server at endpoint.
+func NewA2AClient(endpoint string, opts ...Option) (*A2AClient, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parse endpoint error: %w", err)
+	}
+
+	a := &A2AClient{
+		endpoint: *u,
+		header:   make(map[string]string),
+		client:   http.DefaultClient,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a, nil
+}
+
 // AgentCard implements protocol.IA2AProtocol.
+// It returns the card fetched by [A2AClient.FetchAgentCard], fetching it if not cached,
+// or the zero card if it cannot be fetched.
 func (a *A2AClient) AgentCard() protocol.AgentCard {
-	panic("unimplemented")
+	card, err := a.FetchAgentCard(context.Background())
+	if err != nil {
+		return protocol.AgentCard{}
+	}
+
+	return *card
+}
+
+// FetchAgentCard returns the agent card published by the server at '.well-known/agent.json'
+// under the endpoint path. The card is cached as long as its Cache-Control allows, or
+// [DefaultCardTTL] without one, then revalidated with its ETag. A failed fetch is cached for
+// [CardFailureTTL]. With [WithCardVerifier], a card not properly signed is rejected.
+func (a *A2AClient) FetchAgentCard(ctx context.Context) (*protocol.AgentCard, error) {
+	a.cardMu.Lock()
+	defer a.cardMu.Unlock()
+
+	now := time.Now()
+	if a.card != nil && (a.cardExpires.IsZero() || now.Before(a.cardExpires)) {
+		return a.card, nil
+	}
+
+	if a.cardErr != nil && now.Before(a.cardErrExpires) {
+		return nil, a.cardErr
+	}
+
+	card, err := a.fetchAgentCard(ctx)
+	if err != nil {
+		// the request canceled by the caller says nothing of the server.
+		if ctx.Err() == nil {
+			a.cardErr, a.cardErrExpires = err, now.Add(CardFailureTTL)
+		}
+
+		return nil, err
+	}
+
+	a.card, a.cardErr = card, nil
+	return card, nil
+}
+
+// fetchAgentCard fetches the agent card, revalidating the cached one if any.
+// It must be called with a.cardMu held.
+func (a *A2AClient) fetchAgentCard(ctx context.Context) (*protocol.AgentCard, error) {
+	cardURL := a.endpoint
+	cardURL.Path = strings.TrimSuffix(cardURL.Path, "/") + "/.well-known/agent.json"
+	cardURL.RawPath, cardURL.RawQuery, cardURL.Fragment = "", "", ""
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cardURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request error: %w", err)
+	}
+
+	for k, v := range a.header {
+		req.Header.Set(k, v)
+	}
+
+	if a.card != nil && a.cardETag != "" {
+		req.Header.Set("If-None-Match", a.cardETag)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch agent card error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && a.card != nil {
+		a.cardExpires = time.Now().Add(cardTTL(resp.Header))
+		return a.card, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch agent card error, http-code: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read agent card error: %w", err)
+	}
+
+	if a.cardVerifier != nil {
+		if err := VerifyAgentCard(ctx, body, resp.Header.Get(protocol.HeaderCardSignature), a.cardVerifier); err != nil {
+			return nil, err
+		}
+	}
+
+	card := new(protocol.AgentCard)
+	if err = json.Unmarshal(body, card); err != nil {
+		return nil, fmt.Errorf("unmarshal agent card error: %w", err)
+	}
+
+	if card.Name == "" || card.Url == "" {
+		return nil, errors.New("invalid agent card: name or url is missing")
+	}
+
+	a.cardETag = resp.Header.Get("ETag")
+	a.cardExpires = time.Now().Add(cardTTL(resp.Header))
+	return card, nil
+}
+
+// cardTTL returns how long the agent card can be used without revalidation, per its Cache-Control.
+func cardTTL(header http.Header) time.Duration {
+	ttl := DefaultCardTTL
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.ToLower(strings.TrimSpace(directive)), "=")
+		switch name {
+		case "no-cache", "no-store":
+			return 0
+		case "max-age":
+			if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil {
+				ttl = time.Duration(max(seconds, 0)) * time.Second
+			}
+		}
+	}
+
+	return ttl
+}
+
+// VerifyAgentCard checks the detached JWS signature of the agent card body, as sent
+// in the [protocol.HeaderCardSignature] header, with the key resolved by keyFunc.
+// It is meant for cards fetched from third parties, e.g. through a registry.
+func VerifyAgentCard(ctx context.Context, body []byte, signature string, keyFunc KeyFunc) error {
+	if signature == "" {
+		return errors.New("agent card is not signed")
+	}
+
+	token, err := jws.ParseDetached(signature, body)
+	if err != nil {
+		return fmt.Errorf("parse agent card signature error: %w", err)
+	}
+
+	key, err := keyFunc(ctx, token.Header.Alg, token.Header.Kid)
+	if err != nil {
+		return fmt.Errorf("resolve key error: %w", err)
+	}
+
+	if err := token.Verify(key); err != nil {
+		return fmt.Errorf("verify agent card error: %w", err)
+	}
+
+	return nil
+}
+
+// checkMethod returns the error answering the method if the agent card rules it out,
+// see [protocol.AgentCard.CheckMethod]. Without card, the server is left to answer.
+func (a *A2AClient) checkMethod(ctx context.Context, method protocol.A2AMethod) error {
+	card, err := a.FetchAgentCard(ctx)
+	if err != nil {
+		return nil
+	}
+
+	return card.CheckMethod(method)
 }
 
 // CancelTask implements protocol.IA2AProtocol.
@@ -87,6 +280,10 @@ func (a *A2AClient) GetTask(ctx context.Context, params *protocol.TaskSendParams
 
 // GetTaskPushNotifications implements protocol.IA2AProtocol.
 func (a *A2AClient) GetTaskPushNotifications(ctx context.Context, params *protocol.TaskPushNotificationConfig) (*protocol.TaskPushNotificationConfig, error) {
+	if err := a.checkMethod(ctx, protocol.MethodGetTaskPushNotifications); err != nil {
+		return nil, err
+	}
+
 	ret, err := a.sendRequest(ctx, protocol.MethodGetTaskPushNotifications, params, false)
 	if err != nil {
 		return nil, err
@@ -129,6 +326,10 @@ func (a *A2AClient) SendTask(ctx context.Context, params *protocol.TaskSendParam
 
 // SetTaskPushNotifications implements protocol.IA2AProtocol.
 func (a *A2AClient) SetTaskPushNotifications(ctx context.Context, params *protocol.TaskPushNotificationConfig) (*protocol.TaskPushNotificationConfig, error) {
+	if err := a.checkMethod(ctx, protocol.MethodSetTaskPushNotifications); err != nil {
+		return nil, err
+	}
+
 	ret, err := a.sendRequest(ctx, protocol.MethodSetTaskPushNotifications, params, false)
 	if err != nil {
 		return nil, err
@@ -148,10 +349,36 @@ func (a *A2AClient) SetTaskPushNotifications(ctx context.Context, params *protoc
 	return config, nil
 }
 
+// ListTasks implements protocol.ITaskLister, with the tasks/list extension method
+// the server must enable, see [protocol.ListTasksParams].
+func (a *A2AClient) ListTasks(ctx context.Context, params *protocol.ListTasksParams) (*protocol.ListTasksResult, error) {
+	ret, err := Call[protocol.ListTasksResult](ctx, a, protocol.MethodListTasks, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
 // SubscribeTask implements protocol.IA2AProtocol.
+// [protocol.ErrUnsupportedOperation] is returned without opening the stream if the agent card
+// says streaming is not supported.
 func (a *A2AClient) SubscribeTask(ctx context.Context, params *protocol.TaskSendParams) (chan any, error) {
-	// TODO: Test if stream supported by server.
-	ret, err := a.sendRequest(ctx, protocol.MethodSubscribeTask, params, true)
+	return a.subscribe(ctx, protocol.MethodSubscribeTask, params)
+}
+
+// ResubscribeTask implements protocol.IA2AProtocol.
+func (a *A2AClient) ResubscribeTask(ctx context.Context, params *protocol.TaskSendParams) (chan any, error) {
+	return a.subscribe(ctx, protocol.MethodResubscribeTask, params)
+}
+
+// subscribe opens the task stream of the method, decoding its events.
+func (a *A2AClient) subscribe(ctx context.Context, method protocol.A2AMethod, params *protocol.TaskSendParams) (chan any, error) {
+	if err := a.checkMethod(ctx, method); err != nil {
+		return nil, err
+	}
+
+	ret, err := a.sendRequest(ctx, method, params, true)
 	if err != nil {
 		return nil, err
 	}
@@ -190,11 +417,6 @@ func (a *A2AClient) SubscribeTask(ctx context.Context, params *protocol.TaskSend
 	return ch, nil
 }
 
-// ResubscribeTask implements protocol.IA2AProtocol.
-func (a *A2AClient) ResubscribeTask(ctx context.Context, params *protocol.TaskSendParams) (chan any, error) {
-	return a.SubscribeTask(ctx, params)
-}
-
 // sendRequest handles both
 func (a *A2AClient) sendRequest(
 	ctx context.Context,
@@ -236,6 +458,26 @@ func (a *A2AClient) sendRequest(
 		return nil, fmt.Errorf("server error, http-code: %s, body: %s", resp.Status, string(body))
 	}
 
+	// a server not opening the stream answers with a plain response, e.g. an error.
+	if stream && strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		defer resp.Body.Close()
+
+		raw := new(JsonRpcRaw)
+		if err = json.NewDecoder(resp.Body).Decode(raw); err != nil {
+			return nil, fmt.Errorf("unmarshal response error: %w", err)
+		}
+
+		if raw.Error != nil {
+			return nil, raw.Error
+		}
+
+		ch := make(chan *JsonRpcRaw, 1)
+		ch <- raw
+		close(ch)
+
+		return ch, nil
+	}
+
 	if !stream {
 		defer resp.Body.Close()
 		respBody, err := io.ReadAll(resp.Body)
@@ -265,18 +507,21 @@ func (a *A2AClient) sendRequest(
 
 func (c *A2AClient) readSSE(reader io.ReadCloser, ch chan<- *JsonRpcRaw) {
 	defer reader.Close()
+	defer close(ch)
 
 	br := bufio.NewReader(reader)
-	var data string
+	var data []string
 
 	for {
-		// NOTICE:
-		// Here we assume that one line is one event.
 		// For JSON-RPC response, the format should be:
+		//   - id: 1745734440-1\n
+		//   - event: message\n
 		//   - data: {"jsonrpc": "2.0", "id": 1, "result": {"taskId": "123"}}\n\n
+		// 'data' may span multiple lines, which are joined with '\n'.
 		line, err := br.ReadString('\n')
-		if err != nil {
-			// TODO:
+		if err != nil && len(line) == 0 {
+			// stream closed by server or broken.
+			return
 		}
 
 		line = strings.TrimRight(line, "\r\n")
@@ -288,24 +533,24 @@ func (c *A2AClient) readSSE(reader io.ReadCloser, ch chan<- *JsonRpcRaw) {
 			}
 
 			raw := new(JsonRpcRaw)
-			err = json.Unmarshal([]byte(data), raw)
+			err = json.Unmarshal([]byte(strings.Join(data, "\n")), raw)
 			if err != nil {
 				// TODO:
 			}
 
 			ch <- raw
-			data = ""
+			data = data[:0]
 			continue
 		}
 
-		// if not empty line, must be begin with 'data:'
+		// only 'data' is used, other fields ('id', 'event', 'retry') and comments are ignored.
 		if !strings.HasPrefix(line, "data:") {
-			// TODO:
 			continue
 		}
 
-		data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
 	}
 }
 
 var _ protocol.IA2AProtocol = (*A2AClient)(nil)
+var _ protocol.ITaskLister = (*A2AClient)(nil)
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+const testCard = `{"name":"test","url":"http://localhost","version":"1.0.0","capabilities":{},"skills":[]}`
+
+func TestFetchAgentCard(t *testing.T) {
+	tests := []struct {
+		name         string
+		endpoint     string
+		body         string
+		cacheControl string
+		etag         string
+		wantErr      bool
+		wantFetches  int32
+	}{
+		{name: "cached per max-age", body: testCard, cacheControl: "public, max-age=60", wantFetches: 1},
+		{name: "under the endpoint path", endpoint: "/recipes", body: testCard, wantFetches: 1},
+		{name: "revalidated with no-cache", body: testCard, cacheControl: "no-cache", etag: `"v1"`, wantFetches: 2},
+		{name: "card without name", body: `{"url":"http://localhost"}`, wantErr: true, wantFetches: 1},
+		{name: "card without url", body: `{"name":"test"}`, wantErr: true, wantFetches: 1},
+		{name: "failure is cached", wantErr: true, wantFetches: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetches atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path != tt.endpoint+"/.well-known/agent.json" {
+					http.NotFound(w, req)
+					return
+				}
+
+				fetches.Add(1)
+				if tt.body == "" {
+					http.Error(w, "unavailable", http.StatusServiceUnavailable)
+					return
+				}
+
+				w.Header().Set("Cache-Control", tt.cacheControl)
+				w.Header().Set("ETag", tt.etag)
+				if tt.etag != "" && req.Header.Get("If-None-Match") == tt.etag {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			a, err := NewA2AClient(srv.URL + tt.endpoint)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < 2; i++ {
+				card, err := a.FetchAgentCard(context.Background())
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("FetchAgentCard = %v, %v, want error %v", card, err, tt.wantErr)
+				}
+
+				if err == nil && card.Name != "test" {
+					t.Fatalf("FetchAgentCard = %+v, want the test card", card)
+				}
+			}
+
+			if got := fetches.Load(); got != tt.wantFetches {
+				t.Fatalf("fetched %d times, want %d", got, tt.wantFetches)
+			}
+		})
+	}
+}
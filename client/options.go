@@ -0,0 +1,39 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+// Option configures an [A2AClient].
+type Option func(*A2AClient)
+
+// WithHTTPClient sets the http client sending the requests. Default is [http.DefaultClient].
+func WithHTTPClient(client *http.Client) Option {
+	return func(a *A2AClient) {
+		a.client = client
+	}
+}
+
+// WithHeader sets a header sent with every request, e.g. 'Authorization'.
+func WithHeader(key, value string) Option {
+	return func(a *A2AClient) {
+		a.header[key] = value
+	}
+}
+
+// WithAgentCard sets the agent card of the server, instead of fetching it.
+func WithAgentCard(card protocol.AgentCard) Option {
+	return func(a *A2AClient) {
+		a.card = &card
+	}
+}
+
+// WithCardVerifier makes [A2AClient.FetchAgentCard] reject agent cards not signed by a key
+// resolved through keyFunc, see [VerifyAgentCard].
+func WithCardVerifier(keyFunc KeyFunc) Option {
+	return func(a *A2AClient) {
+		a.cardVerifier = keyFunc
+	}
+}
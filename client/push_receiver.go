@@ -0,0 +1,446 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/internal/jws"
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+var (
+	ErrNotificationUnauthorized = errors.New("push notification unauthorized")
+	ErrNotificationMalformed    = errors.New("malformed push notification")
+)
+
+type (
+	// PushHandler is called for every accepted push notification of a task.
+	// The event is either *protocol.TaskStatusUpdateEvent or *protocol.TaskArtifactUpdateEvent,
+	// the same as the events produced by [A2AClient.SubscribeTask].
+	// Returning an error answers the notification with 503, so the sender retries it later.
+	PushHandler func(ctx context.Context, event any) error
+
+	// KeyFunc resolves the key used to verify a push notification JWT,
+	// typically by looking up 'kid' in the JWKS published by the remote agent.
+	// See [jws] for the key types accepted for each algorithm.
+	KeyFunc func(ctx context.Context, alg string, kid string) (any, error)
+
+	PushReceiverOption func(*PushReceiver)
+
+	// PushReceiver is an http.Handler receiving push notifications sent by remote agents.
+	//
+	// It answers the URL-validation challenge, verifies each notification by token or JWT,
+	// decodes it into a typed event, drops retried duplicates and dispatches it to the
+	// handlers registered for the task.
+	//
+	// Notifications are rejected unless a token or a JWT verification is set,
+	// see [WithNotificationToken], [WithNotificationJWT] and [WithInsecureNotifications].
+	PushReceiver struct {
+		token      string
+		keyFunc    KeyFunc
+		jwtMaxAge  time.Duration
+		insecure   bool
+		dedupTTL   time.Duration
+		maxBody    int64
+		fallback   PushHandler
+		validation bool
+
+		mu       sync.RWMutex
+		handlers map[string]PushHandler
+
+		seenMu    sync.Mutex
+		seen      map[string]time.Time
+		inflight  map[string]struct{}
+		nextSweep time.Time
+	}
+)
+
+// WithNotificationToken accepts the notifications carrying token in the
+// [protocol.HeaderNotificationToken] header, i.e. the token set in [protocol.PushNotificationConfig].
+// With [WithNotificationJWT] too, a notification is accepted if either the token or the JWT is valid.
+func WithNotificationToken(token string) PushReceiverOption {
+	return func(r *PushReceiver) {
+		r.token = token
+	}
+}
+
+// WithNotificationJWT accepts the notifications carrying a JWT as a bearer token,
+// signed by a key resolved through keyFunc and issued no more than maxAge ago.
+// The JWT must contain the [protocol.ClaimRequestBodySHA256] claim matching the body.
+func WithNotificationJWT(keyFunc KeyFunc, maxAge time.Duration) PushReceiverOption {
+	return func(r *PushReceiver) {
+		r.keyFunc = keyFunc
+		r.jwtMaxAge = maxAge
+	}
+}
+
+// WithInsecureNotifications accepts unauthenticated notifications when neither a token nor
+// a JWT verification is set, e.g. for local development. Anyone reaching the receiver can
+// then push events of any task.
+func WithInsecureNotifications() PushReceiverOption {
+	return func(r *PushReceiver) {
+		r.insecure = true
+	}
+}
+
+// WithDedupWindow sets how long a notification id is remembered to drop retries.
+// Default is 10 minutes.
+func WithDedupWindow(d time.Duration) PushReceiverOption {
+	return func(r *PushReceiver) {
+		r.dedupTTL = d
+	}
+}
+
+// WithFallbackHandler sets the handler for notifications of tasks without a registered handler.
+// Without fallback, such notifications are answered with 404.
+func WithFallbackHandler(h PushHandler) PushReceiverOption {
+	return func(r *PushReceiver) {
+		r.fallback = h
+	}
+}
+
+// WithURLValidation enables or disables answering the URL-validation challenge. Default is enabled.
+func WithURLValidation(enable bool) PushReceiverOption {
+	return func(r *PushReceiver) {
+		r.validation = enable
+	}
+}
+
+// WithMaxNotificationSize limits the size of a notification body. Default is 4MB.
+func WithMaxNotificationSize(n int64) PushReceiverOption {
+	return func(r *PushReceiver) {
+		r.maxBody = n
+	}
+}
+
+func NewPushReceiver(opts ...PushReceiverOption) *PushReceiver {
+	r := &PushReceiver{
+		jwtMaxAge:  5 * time.Minute,
+		dedupTTL:   10 * time.Minute,
+		maxBody:    4 << 20,
+		validation: true,
+		handlers:   make(map[string]PushHandler),
+		seen:       make(map[string]time.Time),
+		inflight:   make(map[string]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Handle registers the handler for notifications of the task, replacing the previous one.
+func (r *PushReceiver) Handle(taskID string, h PushHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handlers[taskID] = h
+}
+
+// Channel registers a channel receiving the notifications of the task.
+// The returned function unregisters the task and closes the channel.
+//
+// Delivery blocks until the channel accepts the event or the notification request ends,
+// in the latter case the sender gets a 503 and is expected to retry.
+func (r *PushReceiver) Channel(taskID string, size int) (<-chan any, func()) {
+	ch := make(chan any, size)
+
+	// protects ch from being closed while a delivery is in progress.
+	var chMu sync.RWMutex
+	closed := false
+
+	r.Handle(taskID, func(ctx context.Context, event any) error {
+		chMu.RLock()
+		defer chMu.RUnlock()
+
+		if closed {
+			return nil
+		}
+
+		select {
+		case ch <- event:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			r.Unregister(taskID)
+
+			chMu.Lock()
+			closed = true
+			close(ch)
+			chMu.Unlock()
+		})
+	}
+}
+
+// Unregister removes the handler of the task.
+func (r *PushReceiver) Unregister(taskID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.handlers, taskID)
+}
+
+// ServeHTTP implements http.Handler.
+func (r *PushReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	switch req.Method {
+	case http.MethodGet:
+		r.serveValidation(w, req)
+	case http.MethodPost:
+		r.serveNotification(w, req)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (r *PushReceiver) serveValidation(w http.ResponseWriter, req *http.Request) {
+	token := req.URL.Query().Get(protocol.QueryValidationToken)
+	if !r.validation || token == "" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, token)
+}
+
+func (r *PushReceiver) serveNotification(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(req.Body, r.maxBody+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if int64(len(body)) > r.maxBody {
+		http.Error(w, "notification too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err := r.authenticate(req, body); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	taskID, event, err := decodeNotification(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	r.mu.RLock()
+	handler, ok := r.handlers[taskID]
+	r.mu.RUnlock()
+
+	if !ok {
+		handler = r.fallback
+	}
+
+	if handler == nil {
+		http.Error(w, fmt.Sprintf("no handler for task [%s]", taskID), http.StatusNotFound)
+		return
+	}
+
+	id := req.Header.Get(protocol.HeaderNotificationID)
+	if id == "" {
+		sum := sha256.Sum256(body)
+		id = hex.EncodeToString(sum[:])
+	}
+
+	switch r.claim(id) {
+	case claimSeen:
+		// duplicated notification has already been dispatched, just ack it.
+		w.WriteHeader(http.StatusOK)
+		return
+	case claimInflight:
+		// the first attempt may still fail, let the sender retry until it succeeds.
+		http.Error(w, "notification is being dispatched", http.StatusConflict)
+		return
+	}
+
+	err = handler(req.Context(), event)
+	r.release(id, err == nil)
+	if err != nil {
+		// let the sender retry.
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// authenticate accepts the notification with a valid token or a valid JWT.
+func (r *PushReceiver) authenticate(req *http.Request, body []byte) error {
+	if r.token == "" && r.keyFunc == nil {
+		if r.insecure {
+			return nil
+		}
+
+		return fmt.Errorf("%w: no token nor JWT verification configured", ErrNotificationUnauthorized)
+	}
+
+	var errs []error
+	if r.token != "" {
+		got := req.Header.Get(protocol.HeaderNotificationToken)
+		if subtle.ConstantTimeCompare([]byte(got), []byte(r.token)) == 1 {
+			return nil
+		}
+
+		errs = append(errs, errors.New("token mismatch"))
+	}
+
+	if r.keyFunc != nil {
+		err := r.verifyJWT(req, body)
+		if err == nil {
+			return nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	return fmt.Errorf("%w: %v", ErrNotificationUnauthorized, errors.Join(errs...))
+}
+
+func (r *PushReceiver) verifyJWT(req *http.Request, body []byte) error {
+	auth := req.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		return errors.New("missing bearer token")
+	}
+
+	token, err := jws.Parse(strings.TrimSpace(auth[7:]))
+	if err != nil {
+		return err
+	}
+
+	key, err := r.keyFunc(req.Context(), token.Header.Alg, token.Header.Kid)
+	if err != nil {
+		return fmt.Errorf("resolve key error: %w", err)
+	}
+
+	if err := token.Verify(key); err != nil {
+		return err
+	}
+
+	claims := struct {
+		IssuedAt   *int64 `json:"iat"`
+		BodySHA256 string `json:"request_body_sha256"`
+	}{}
+	if err := json.Unmarshal(token.Payload, &claims); err != nil {
+		return fmt.Errorf("decode claims error: %w", err)
+	}
+
+	if claims.IssuedAt == nil {
+		return errors.New("missing iat claim")
+	}
+
+	if age := time.Since(time.Unix(*claims.IssuedAt, 0)); age > r.jwtMaxAge || age < -time.Minute {
+		return errors.New("token expired")
+	}
+
+	sum := sha256.Sum256(body)
+	if subtle.ConstantTimeCompare([]byte(claims.BodySHA256), []byte(hex.EncodeToString(sum[:]))) != 1 {
+		return fmt.Errorf("%s mismatch", protocol.ClaimRequestBodySHA256)
+	}
+
+	return nil
+}
+
+type claimResult int
+
+const (
+	claimed claimResult = iota
+	claimSeen
+	claimInflight
+)
+
+// claim marks the notification id in flight, unless it has already been dispatched
+// within the dedup window, or is being dispatched.
+func (r *PushReceiver) claim(id string) claimResult {
+	r.seenMu.Lock()
+	defer r.seenMu.Unlock()
+
+	now := time.Now()
+
+	// expired ids are swept at most once per window, not on every notification.
+	if now.After(r.nextSweep) {
+		for k, at := range r.seen {
+			if now.Sub(at) > r.dedupTTL {
+				delete(r.seen, k)
+			}
+		}
+
+		r.nextSweep = now.Add(r.dedupTTL)
+	}
+
+	if at, ok := r.seen[id]; ok && now.Sub(at) <= r.dedupTTL {
+		return claimSeen
+	}
+
+	if _, ok := r.inflight[id]; ok {
+		return claimInflight
+	}
+
+	r.inflight[id] = struct{}{}
+	return claimed
+}
+
+// release ends the dispatch of the notification id, remembering it if dispatched.
+func (r *PushReceiver) release(id string, dispatched bool) {
+	r.seenMu.Lock()
+	defer r.seenMu.Unlock()
+
+	delete(r.inflight, id)
+	if dispatched {
+		r.seen[id] = time.Now()
+	}
+}
+
+// decodeNotification decodes the body into a typed event, see [A2AClient.SubscribeTask].
+func decodeNotification(body []byte) (string, any, error) {
+	msg := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrNotificationMalformed, err)
+	}
+
+	if msg["status"] != nil {
+		event := new(protocol.TaskStatusUpdateEvent)
+		if err := json.Unmarshal(body, event); err != nil {
+			return "", nil, fmt.Errorf("%w: %v", ErrNotificationMalformed, err)
+		}
+
+		return event.ID, event, nil
+	}
+
+	if msg["artifact"] != nil {
+		event := new(protocol.TaskArtifactUpdateEvent)
+		if err := json.Unmarshal(body, event); err != nil {
+			return "", nil, fmt.Errorf("%w: %v", ErrNotificationMalformed, err)
+		}
+
+		return event.ID, event, nil
+	}
+
+	return "", nil, fmt.Errorf("%w: neither status nor artifact update", ErrNotificationMalformed)
+}
+
+var _ http.Handler = (*PushReceiver)(nil)
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/internal/jws"
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+const testNotification = `{"id":"t1","status":{"state":"working"}}`
+
+var testJWTKey = []byte("secret")
+
+func testJWT(t *testing.T, body string) string {
+	sum := sha256.Sum256([]byte(body))
+	payload, _ := json.Marshal(map[string]any{
+		"iat":                           time.Now().Unix(),
+		protocol.ClaimRequestBodySHA256: hex.EncodeToString(sum[:]),
+	})
+
+	token, err := jws.Sign("HS256", "k1", testJWTKey, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func testKeyFunc(ctx context.Context, alg, kid string) (any, error) {
+	return testJWTKey, nil
+}
+
+func postNotification(r *PushReceiver, id string, header map[string]string) int {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(testNotification))
+	req.Header.Set(protocol.HeaderNotificationID, id)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestPushReceiverAuthentication(t *testing.T) {
+	jwt := "Bearer " + testJWT(t, testNotification)
+
+	tests := []struct {
+		name   string
+		opts   []PushReceiverOption
+		header map[string]string
+		want   int
+	}{
+		{
+			name: "no authentication configured",
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "insecure opt-in",
+			opts: []PushReceiverOption{WithInsecureNotifications()},
+			want: http.StatusOK,
+		},
+		{
+			name:   "token",
+			opts:   []PushReceiverOption{WithNotificationToken("tok")},
+			header: map[string]string{protocol.HeaderNotificationToken: "tok"},
+			want:   http.StatusOK,
+		},
+		{
+			name:   "wrong token",
+			opts:   []PushReceiverOption{WithNotificationToken("tok")},
+			header: map[string]string{protocol.HeaderNotificationToken: "bad"},
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "jwt",
+			opts:   []PushReceiverOption{WithNotificationJWT(testKeyFunc, time.Minute)},
+			header: map[string]string{"Authorization": jwt},
+			want:   http.StatusOK,
+		},
+		{
+			name:   "token or jwt, token only",
+			opts:   []PushReceiverOption{WithNotificationToken("tok"), WithNotificationJWT(testKeyFunc, time.Minute)},
+			header: map[string]string{protocol.HeaderNotificationToken: "tok"},
+			want:   http.StatusOK,
+		},
+		{
+			name:   "token or jwt, jwt only",
+			opts:   []PushReceiverOption{WithNotificationToken("tok"), WithNotificationJWT(testKeyFunc, time.Minute)},
+			header: map[string]string{"Authorization": jwt},
+			want:   http.StatusOK,
+		},
+		{
+			name:   "token or jwt, neither",
+			opts:   []PushReceiverOption{WithNotificationToken("tok"), WithNotificationJWT(testKeyFunc, time.Minute)},
+			header: map[string]string{protocol.HeaderNotificationToken: "bad"},
+			want:   http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewPushReceiver(append(tt.opts, WithFallbackHandler(func(ctx context.Context, event any) error {
+				return nil
+			}))...)
+
+			if got := postNotification(r, "n1", tt.header); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPushReceiverDedup(t *testing.T) {
+	var calls atomic.Int32
+	failing := atomic.Bool{}
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+
+	r := NewPushReceiver(WithInsecureNotifications(), WithFallbackHandler(func(ctx context.Context, event any) error {
+		calls.Add(1)
+		if failing.Load() {
+			return errors.New("unavailable")
+		}
+
+		select {
+		case entered <- struct{}{}:
+			<-unblock
+		default:
+		}
+
+		return nil
+	}))
+
+	// a failed dispatch is retried.
+	failing.Store(true)
+	if got := postNotification(r, "n1", nil); got != http.StatusServiceUnavailable {
+		t.Fatalf("failed dispatch status = %d, want %d", got, http.StatusServiceUnavailable)
+	}
+
+	failing.Store(false)
+
+	// a duplicate of an in-flight notification is not acked, the first attempt may still fail.
+	done := make(chan int)
+	go func() {
+		done <- postNotification(r, "n1", nil)
+	}()
+
+	<-entered
+	if got := postNotification(r, "n1", nil); got != http.StatusConflict {
+		t.Errorf("in-flight duplicate status = %d, want %d", got, http.StatusConflict)
+	}
+
+	close(unblock)
+	if got := <-done; got != http.StatusOK {
+		t.Errorf("retry status = %d, want %d", got, http.StatusOK)
+	}
+
+	// a duplicate of a dispatched notification is acked without dispatching it again.
+	if got := postNotification(r, "n1", nil); got != http.StatusOK {
+		t.Errorf("dispatched duplicate status = %d, want %d", got, http.StatusOK)
+	}
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("handler calls = %d, want 2", got)
+	}
+}
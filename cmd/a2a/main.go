@@ -0,0 +1,190 @@
+// Command a2a is a command line client of A2A servers.
+//
+// Usage:
+//
+//	a2a [-endpoint url] [-token token] tasks list [flags]
+//
+// tasks list lists the tasks visible to the caller through the tasks/list extension method,
+// following the cursors unless -page-size is set.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/client"
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "a2a:", err)
+		os.Exit(1)
+	}
+}
+
+// run runs the command of args, printing its output to stdout.
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("a2a", flag.ContinueOnError)
+	endpoint := fs.String("endpoint", "http://localhost:8080", "JSON-RPC endpoint of the agent")
+	token := fs.String("token", os.Getenv("A2A_TOKEN"), "bearer token of the caller, default is $A2A_TOKEN")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: a2a [flags] tasks list [flags]")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var opts []client.Option
+	if *token != "" {
+		opts = append(opts, client.WithHeader("Authorization", "Bearer "+*token))
+	}
+
+	c, err := client.NewA2AClient(*endpoint, opts...)
+	if err != nil {
+		return err
+	}
+
+	switch strings.Join(fs.Args()[:min(2, fs.NArg())], " ") {
+	case "tasks list":
+		return listTasks(ctx, c, fs.Args()[2:], stdout)
+	default:
+		fs.Usage()
+		return errors.New("unknown command")
+	}
+}
+
+// listTasks runs 'tasks list'.
+func listTasks(ctx context.Context, c *client.A2AClient, args []string, stdout io.Writer) error {
+	var (
+		params   protocol.ListTasksParams
+		metadata = make(map[string]any)
+	)
+
+	fs := flag.NewFlagSet("tasks list", flag.ContinueOnError)
+	session := fs.String("session", "", "only the tasks of the session")
+	states := fs.String("state", "", "only the tasks in one of the comma separated states, e.g. working,input-required")
+	after := fs.String("after", "", "only the tasks updated at or after the RFC 3339 time")
+	before := fs.String("before", "", "only the tasks updated at or before the RFC 3339 time")
+	pageSize := fs.Int("page-size", 0, "list a single page of this size, default lists every page")
+	fs.StringVar(&params.Cursor, "cursor", "", "cursor of the page to list")
+	asJSON := fs.Bool("json", false, "print the tasks as JSON lines")
+	fs.Func("metadata", "only the tasks having the metadata key, with the value if given as key=value, repeatable.\n"+
+		"The value is JSON, e.g. priority=2 or urgent=true, or else a string", func(v string) error {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok {
+			metadata[key] = nil
+			return nil
+		}
+
+		// compared with the metadata of the tasks as decoded from JSON.
+		var decoded any
+		if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+			decoded = value
+		}
+
+		metadata[key] = decoded
+		return nil
+	})
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *session != "" {
+		params.SessionID = session
+	}
+
+	if *states != "" {
+		for _, state := range strings.Split(*states, ",") {
+			params.States = append(params.States, protocol.TaskState(strings.TrimSpace(state)))
+		}
+	}
+
+	var err error
+	if params.UpdatedAfter, err = parseTime(*after); err != nil {
+		return fmt.Errorf("-after: %w", err)
+	}
+
+	if params.UpdatedBefore, err = parseTime(*before); err != nil {
+		return fmt.Errorf("-before: %w", err)
+	}
+
+	if len(metadata) > 0 {
+		params.Metadata = metadata
+	}
+
+	if *pageSize > 0 {
+		params.PageSize = pageSize
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	if !*asJSON {
+		fmt.Fprintln(w, "ID\tSESSION\tSTATE\tUPDATED")
+	}
+
+	for {
+		ret, err := c.ListTasks(ctx, &params)
+		if err != nil {
+			return err
+		}
+
+		for _, task := range ret.Tasks {
+			if *asJSON {
+				line, _ := json.Marshal(task)
+				fmt.Fprintln(w, string(line))
+				continue
+			}
+
+			updated := ""
+			if task.Status.Timestamp != nil {
+				updated = *task.Status.Timestamp
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", task.ID, task.SessionID, task.Status.State, updated)
+		}
+
+		if ret.NextCursor == "" {
+			break
+		}
+
+		// a single page was asked for, tell how to get the next one.
+		if params.PageSize != nil {
+			w.Flush()
+			fmt.Fprintf(stdout, "next page: -cursor %s\n", ret.NextCursor)
+			return nil
+		}
+
+		params.Cursor = ret.NextCursor
+	}
+
+	return w.Flush()
+}
+
+// parseTime parses an RFC 3339 time, nil if empty.
+func parseTime(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
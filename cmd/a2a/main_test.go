@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zhengrenjie/go-a2a/client"
+	"github.com/zhengrenjie/go-a2a/protocol"
+	"github.com/zhengrenjie/go-a2a/server"
+)
+
+func TestTasksList(t *testing.T) {
+	card := protocol.AgentCard{Name: "test", Url: "http://localhost", Version: "1.0.0"}
+	s := server.NewA2AServerWithExecutor(card, server.AgentExecutorFunc(
+		func(ctx context.Context, task *protocol.Task, updater *server.TaskUpdater) error {
+			if task.SessionID == "waiting" {
+				return updater.RequireInput(ctx)
+			}
+
+			return updater.Complete(ctx)
+		}), server.WithListTasksMethod(nil))
+
+	host := server.NewMultiA2AHost("")
+	host.Mount("/", s)
+	srv := httptest.NewServer(host.Handler())
+	defer srv.Close()
+
+	c, err := client.NewA2AClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := []map[string]any{{"priority": 1, "tag": "urgent"}, {"priority": 2, "tag": "2"}, nil}
+	for i, session := range []string{"done", "done", "waiting"} {
+		params := &protocol.TaskSendParams{
+			ID:        fmt.Sprintf("t%d", i),
+			SessionID: &session,
+			Message:   protocol.Message{Role: protocol.RoleUser, Parts: []protocol.Part{protocol.NewTextPart("hello")}},
+			Metadata:  metadata[i],
+		}
+
+		if _, err := c.SendTask(context.Background(), params); err != nil {
+			t.Fatalf("SendTask = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "every task", args: []string{"-page-size", "0"}, want: []string{"t0", "t1", "t2"}},
+		{name: "by state", args: []string{"-state", "input-required"}, want: []string{"t2"}},
+		{name: "by session", args: []string{"-session", "done"}, want: []string{"t0", "t1"}},
+		{name: "json", args: []string{"-json", "-state", "completed,canceled"}, want: []string{`"id":"t0"`, `"id":"t1"`}},
+		{name: "by metadata key", args: []string{"-metadata", "priority"}, want: []string{"t0", "t1"}},
+		{name: "by json metadata", args: []string{"-metadata", "priority=2"}, want: []string{"t1"}},
+		{name: "by string metadata", args: []string{"-metadata", "tag=urgent"}, want: []string{"t0"}},
+		{name: "by quoted metadata", args: []string{"-metadata", `tag="2"`}, want: []string{"t1"}},
+		{name: "single page", args: []string{"-page-size", "1", "-state", "completed"}, want: []string{"t", "next page: -cursor "}},
+		{name: "invalid time", args: []string{"-after", "yesterday"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := run(context.Background(), append([]string{"-endpoint", srv.URL, "tasks", "list"}, tt.args...), &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("run = %v, want error %v", err, tt.wantErr)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Fatalf("output\n%s\nwant %s", out.String(), want)
+				}
+			}
+
+			if lines := strings.Count(out.String(), "\n"); err == nil && lines != len(tt.want)+header(tt.args) {
+				t.Fatalf("output\n%s\nwant %d tasks", out.String(), len(tt.want))
+			}
+		})
+	}
+}
+
+// header returns the number of header lines printed with args.
+func header(args []string) int {
+	for _, arg := range args {
+		if arg == "-json" {
+			return 0
+		}
+	}
+
+	return 1
+}
@@ -0,0 +1,239 @@
+// Package jws implements the small subset of JSON Web Signature (RFC 7515)
+// used by A2A: compact serialization with HS256, RS256, ES256 and EdDSA.
+package jws
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrMalformed        = errors.New("jws: malformed token")
+	ErrUnsupportedAlg   = errors.New("jws: unsupported algorithm")
+	ErrInvalidKey       = errors.New("jws: key does not match algorithm")
+	ErrInvalidSignature = errors.New("jws: invalid signature")
+)
+
+// Header is the protected header of a JWS.
+type Header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// Token is a parsed, not yet verified, compact JWS.
+type Token struct {
+	Header  Header
+	Payload []byte
+
+	signingInput string
+	signature    []byte
+}
+
+var enc = base64.RawURLEncoding
+
+// Parse splits a compact JWS into its parts without verifying the signature.
+func Parse(token string) (*Token, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	rawHeader, err := enc.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrMalformed, err)
+	}
+
+	t := new(Token)
+	if err := json.Unmarshal(rawHeader, &t.Header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrMalformed, err)
+	}
+
+	t.Payload, err = enc.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: payload: %v", ErrMalformed, err)
+	}
+
+	t.signature, err = enc.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrMalformed, err)
+	}
+
+	t.signingInput = parts[0] + "." + parts[1]
+	return t, nil
+}
+
+// Verify checks the token signature with key.
+// The key type must match the algorithm in the header:
+//   - HS256: []byte
+//   - RS256: *rsa.PublicKey
+//   - ES256: *ecdsa.PublicKey
+//   - EdDSA: ed25519.PublicKey
+func (t *Token) Verify(key any) error {
+	return verify(t.Header.Alg, key, []byte(t.signingInput), t.signature)
+}
+
+func verify(alg string, key any, input, sig []byte) error {
+	switch alg {
+	case AlgHS256:
+		k, ok := key.([]byte)
+		if !ok {
+			return ErrInvalidKey
+		}
+
+		mac := hmac.New(sha256.New, k)
+		mac.Write(input)
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return ErrInvalidSignature
+		}
+	case AlgRS256:
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrInvalidKey
+		}
+
+		digest := sha256.Sum256(input)
+		if rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) != nil {
+			return ErrInvalidSignature
+		}
+	case AlgES256:
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrInvalidKey
+		}
+
+		if len(sig) != 64 {
+			return ErrInvalidSignature
+		}
+
+		digest := sha256.Sum256(input)
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			return ErrInvalidSignature
+		}
+	case AlgEdDSA:
+		k, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return ErrInvalidKey
+		}
+
+		if !ed25519.Verify(k, input, sig) {
+			return ErrInvalidSignature
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedAlg, alg)
+	}
+
+	return nil
+}
+
+// Sign returns the compact JWS of payload.
+// The key type must match alg:
+//   - HS256: []byte
+//   - RS256: *rsa.PrivateKey
+//   - ES256: *ecdsa.PrivateKey
+//   - EdDSA: ed25519.PrivateKey
+func Sign(alg, kid string, key any, payload []byte) (string, error) {
+	header, err := json.Marshal(Header{Alg: alg, Kid: kid, Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	input := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+	sig, err := sign(alg, key, []byte(input))
+	if err != nil {
+		return "", err
+	}
+
+	return input + "." + enc.EncodeToString(sig), nil
+}
+
+func sign(alg string, key any, input []byte) ([]byte, error) {
+	switch alg {
+	case AlgHS256:
+		k, ok := key.([]byte)
+		if !ok {
+			return nil, ErrInvalidKey
+		}
+
+		mac := hmac.New(sha256.New, k)
+		mac.Write(input)
+		return mac.Sum(nil), nil
+	case AlgRS256:
+		k, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, ErrInvalidKey
+		}
+
+		digest := sha256.Sum256(input)
+		return rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case AlgES256:
+		k, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, ErrInvalidKey
+		}
+
+		digest := sha256.Sum256(input)
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			return nil, err
+		}
+
+		// fixed size r || s, see RFC 7518 section 3.4.
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig, nil
+	case AlgEdDSA:
+		k, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, ErrInvalidKey
+		}
+
+		return ed25519.Sign(k, input), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlg, alg)
+	}
+}
+
+// SignDetached returns the compact JWS of payload with the payload left out ('header..signature'),
+// see RFC 7515 appendix F. The payload is conveyed apart, e.g. as the body of a response.
+func SignDetached(alg, kid string, key any, payload []byte) (string, error) {
+	token, err := Sign(alg, kid, key, payload)
+	if err != nil {
+		return "", err
+	}
+
+	parts := strings.Split(token, ".")
+	return parts[0] + ".." + parts[2], nil
+}
+
+// ParseDetached parses a JWS made by [SignDetached] with the payload it was detached from,
+// without verifying the signature.
+func ParseDetached(token string, payload []byte) (*Token, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		return nil, ErrMalformed
+	}
+
+	return Parse(parts[0] + "." + enc.EncodeToString(payload) + "." + parts[2])
+}
@@ -0,0 +1,162 @@
+package jws
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testKeys struct {
+	alg    string
+	sign   any
+	verify any
+}
+
+func newTestKeys(t *testing.T) []testKeys {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return []testKeys{
+		{alg: AlgHS256, sign: []byte("secret"), verify: []byte("secret")},
+		{alg: AlgRS256, sign: rsaKey, verify: &rsaKey.PublicKey},
+		{alg: AlgES256, sign: ecKey, verify: &ecKey.PublicKey},
+		{alg: AlgEdDSA, sign: edPrivate, verify: edPublic},
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	payload := []byte(`{"sub":"agent"}`)
+
+	for _, keys := range newTestKeys(t) {
+		t.Run(keys.alg, func(t *testing.T) {
+			token, err := Sign(keys.alg, "k1", keys.sign, payload)
+			if err != nil {
+				t.Fatalf("Sign = %v", err)
+			}
+
+			parsed, err := Parse(token)
+			if err != nil {
+				t.Fatalf("Parse = %v", err)
+			}
+
+			if parsed.Header.Alg != keys.alg || parsed.Header.Kid != "k1" || string(parsed.Payload) != string(payload) {
+				t.Fatalf("parsed token = %+v", parsed)
+			}
+
+			if err := parsed.Verify(keys.verify); err != nil {
+				t.Fatalf("Verify = %v", err)
+			}
+
+			// a tampered payload doesn't verify.
+			parts := strings.Split(token, ".")
+			tampered, err := Parse(parts[0] + "." + enc.EncodeToString([]byte(`{"sub":"other"}`)) + "." + parts[2])
+			if err != nil {
+				t.Fatalf("Parse tampered = %v", err)
+			}
+
+			if err := tampered.Verify(keys.verify); !errors.Is(err, ErrInvalidSignature) {
+				t.Fatalf("Verify tampered = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestVerifyErrors(t *testing.T) {
+	keys := newTestKeys(t)
+	token, err := Sign(AlgHS256, "", []byte("secret"), []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		key     any
+		wantErr error
+	}{
+		{name: "wrong secret", token: token, key: []byte("other"), wantErr: ErrInvalidSignature},
+		{name: "key of another algorithm", token: token, key: keys[1].verify, wantErr: ErrInvalidKey},
+		{name: "alg none", token: enc.EncodeToString([]byte(`{"alg":"none"}`)) + ".e30.", key: []byte("secret"), wantErr: ErrUnsupportedAlg},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := Parse(tt.token)
+			if err != nil {
+				t.Fatalf("Parse = %v", err)
+			}
+
+			if err := parsed.Verify(tt.key); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	for _, token := range []string{"", "a.b", "a.b.c.d", "!!.e30.", enc.EncodeToString([]byte("{")) + ".e30.", "e30.!!."} {
+		if _, err := Parse(token); !errors.Is(err, ErrMalformed) {
+			t.Errorf("Parse(%q) = %v, want ErrMalformed", token, err)
+		}
+	}
+}
+
+func TestDetached(t *testing.T) {
+	payload := []byte(`{"name":"agent"}`)
+
+	for _, keys := range newTestKeys(t) {
+		t.Run(keys.alg, func(t *testing.T) {
+			token, err := SignDetached(keys.alg, "k1", keys.sign, payload)
+			if err != nil {
+				t.Fatalf("SignDetached = %v", err)
+			}
+
+			if parts := strings.Split(token, "."); len(parts) != 3 || parts[1] != "" {
+				t.Fatalf("SignDetached = %s, want an empty payload", token)
+			}
+
+			tests := []struct {
+				name    string
+				payload []byte
+				wantErr error
+			}{
+				{name: "same payload", payload: payload},
+				{name: "other payload", payload: []byte(`{"name":"other"}`), wantErr: ErrInvalidSignature},
+			}
+
+			for _, tt := range tests {
+				parsed, err := ParseDetached(token, tt.payload)
+				if err != nil {
+					t.Fatalf("ParseDetached = %v", err)
+				}
+
+				if err := parsed.Verify(keys.verify); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("%s: Verify = %v, want %v", tt.name, err, tt.wantErr)
+				}
+			}
+		})
+	}
+
+	// an attached token is not a detached one.
+	attached, _ := Sign(AlgHS256, "", []byte("secret"), payload)
+	if _, err := ParseDetached(attached, payload); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("ParseDetached of an attached token = %v, want ErrMalformed", err)
+	}
+}
@@ -1,5 +1,18 @@
 package protocol
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+const (
+	// HeaderCardSignature carries the detached JWS of the agent card served at
+	// '/.well-known/agent.json', signing the body of the response as is.
+	HeaderCardSignature = "X-A2A-Card-Signature"
+)
+
 type (
 	// An AgentCard conveys key information:
 	// - Overall details (version, name, description, uses)
@@ -10,31 +23,31 @@ type (
 
 		// Human readable name of the agent.
 		// (e.g. "Recipe Agent")
-		Name string `json:name`
+		Name string `json:"name"`
 
 		// A human-readable description of the agent. Used to assist users and
 		// other agents in understanding what the agent can do.
 		// (e.g. "Agent that helps users with recipes and cooking.")
-		Description string `json:description`
+		Description string `json:"description"`
 
 		// A URL to the address the agent is hosted at.
-		Url string `json:url`
+		Url string `json:"url"`
 
 		// The service provider of the agent.
-		Provider *Provider `json:"provder,omitempty"`
+		Provider *Provider `json:"provider,omitempty"`
 
 		// The version of the agent - format is up to the provider. (e.g. "1.0.0")
-		Version string `json:version`
+		Version string `json:"version"`
 
 		// A URL to documentation for the agent.
 		DocumentationUrl *string `json:"documentationUrl,omitempty"`
 
 		// Optional capabilities supported by the agent.
-		Capabilities Capabilities `json:capabilities`
+		Capabilities Capabilities `json:"capabilities"`
 
-		// Authentication requirements for the agent.
+		// Authentication requirements for the agent, left out if none.
 		// Intended to match OpenAPI authentication structure.
-		Authentication Authentication `json:authentication`
+		Authentication *Authentication `json:"authentication,omitempty"`
 
 		// The set of interaction modes that the agent supports across all skills. This can be overridden per-skill.
 		// Supported mime types for input
@@ -43,20 +56,24 @@ type (
 		// Supported mime types for output
 		DefaultOutputModes []string `json:"defaultOutputModes,omitempty"`
 
-		// Skills are a unit of capability that an agent can perform.
-		Skills Skills `json:skills`
+		// Skills are a unit of capability that an agent can perform, at least one is required.
+		// No skills are encoded as an empty list.
+		Skills []Skill `json:"skills"`
+
+		// True if authenticated clients are served an extended card, e.g. with more skills.
+		SupportsAuthenticatedExtendedCard bool `json:"supportsAuthenticatedExtendedCard,omitempty"`
 	}
 
 	// The service provider of the agent.
 	Provider struct {
 		Organization string `json:"organization"`
-		Url          string `json:url`
+		Url          string `json:"url"`
 	}
 
 	// Optional capabilities supported by the agent.
 	Capabilities struct {
 		// True if the agent supports SSE (Server-Sent Events)
-		Streaming *bool `json:streaming,omitempty`
+		Streaming *bool `json:"streaming,omitempty"`
 
 		// True if the agent can notify updates to client.
 		PushNotifications *bool `json:"pushNotifications,omitempty"`
@@ -69,31 +86,140 @@ type (
 	// Intended to match OpenAPI authentication structure.
 	Authentication struct {
 		// e.g. Basic, Bearer
-		Schemes []string `json:schemes`
+		Schemes []string `json:"schemes"`
 
 		// Credentials a client should use for private cards
-		Credentials *string `json:credentials,omitempty`
+		Credentials *string `json:"credentials,omitempty"`
 	}
 
-	Skills struct {
+	// A unit of capability that an agent can perform.
+	Skill struct {
 		// Unique identifier for the agent's skill.
-		Id string `json:id`
+		Id string `json:"id"`
 
 		// Human readable name of the skill.
-		Name string `json:name`
+		Name string `json:"name"`
 
 		// Description of the skill - will be used by the client or a human.
-		Description string `json:description`
+		Description string `json:"description"`
 
 		// Set of tagwords describing classes of capabilities for this specific skill (e.g. "cooking", "customer support", "billing")
-		Tags []string `json:tags`
+		Tags []string `json:"tags"`
 
 		// The set of example scenarios that the skill can perform.
 		// Will be used by the client as a hint to understand how the skill can be used. (e.g. "I need a recipe for bread")
-		Examples []string `json:examples,omitempty`
+		Examples []string `json:"examples,omitempty"`
 
 		// The set of interaction modes that the skill supports (if different than the default)
 		InputModes  []string `json:"inputModes,omitempty"`
 		OutputModes []string `json:"outputModes,omitempty"`
 	}
 )
+
+// Skills is the former name of [Skill].
+//
+// Deprecated: use Skill.
+type Skills = Skill
+
+// MarshalJSON implements json.Marshaler, encoding no skills as an empty list as the spec requires.
+func (c AgentCard) MarshalJSON() ([]byte, error) {
+	type plain AgentCard
+	if c.Skills == nil {
+		c.Skills = []Skill{}
+	}
+
+	return json.Marshal(plain(c))
+}
+
+// Validate reports what makes the card invalid: a missing name, url, version or skills, a url that
+// isn't an absolute http(s) url, or skills without id or sharing an id. Every problem is reported.
+func (c *AgentCard) Validate() error {
+	var errs []error
+	if c.Name == "" {
+		errs = append(errs, errors.New("name is missing"))
+	}
+
+	if c.Url == "" {
+		errs = append(errs, errors.New("url is missing"))
+	} else if err := validateURL(c.Url); err != nil {
+		errs = append(errs, fmt.Errorf("url: %w", err))
+	}
+
+	if c.Version == "" {
+		errs = append(errs, errors.New("version is missing"))
+	}
+
+	if c.DocumentationUrl != nil {
+		if err := validateURL(*c.DocumentationUrl); err != nil {
+			errs = append(errs, fmt.Errorf("documentationUrl: %w", err))
+		}
+	}
+
+	if c.Provider != nil && c.Provider.Url != "" {
+		if err := validateURL(c.Provider.Url); err != nil {
+			errs = append(errs, fmt.Errorf("provider url: %w", err))
+		}
+	}
+
+	if len(c.Skills) == 0 {
+		errs = append(errs, errors.New("skills are missing"))
+	}
+
+	seen := make(map[string]bool, len(c.Skills))
+	for i, skill := range c.Skills {
+		switch {
+		case skill.Id == "":
+			errs = append(errs, fmt.Errorf("skill #%d: id is missing", i))
+		case seen[skill.Id]:
+			errs = append(errs, fmt.Errorf("skill #%d: duplicate id [%s]", i, skill.Id))
+		}
+
+		seen[skill.Id] = true
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("[%s] is not an absolute http(s) url", raw)
+	}
+
+	return nil
+}
+
+// Skill returns the skill of the card with the id.
+func (c *AgentCard) Skill(id string) (*Skill, bool) {
+	for i := range c.Skills {
+		if c.Skills[i].Id == id {
+			return &c.Skills[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// CheckMethod returns the error answering the method if the capabilities of the card rule it out:
+//   - [ErrUnsupportedOperation] for tasks/sendSubscribe and tasks/resubscribe, if Streaming is false.
+//   - [ErrPushNotificationNotSupported] for tasks/pushNotification/set and get, if PushNotifications is false.
+//
+// A capability not set is not checked.
+func (c *AgentCard) CheckMethod(method A2AMethod) error {
+	switch method {
+	case MethodSubscribeTask, MethodResubscribeTask:
+		if v := c.Capabilities.Streaming; v != nil && !*v {
+			return ErrUnsupportedOperation.New().Args(method)
+		}
+	case MethodSetTaskPushNotifications, MethodGetTaskPushNotifications:
+		if v := c.Capabilities.PushNotifications; v != nil && !*v {
+			return ErrPushNotificationNotSupported.New()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestAgentCardGolden decodes the sample cards of the A2A specification, and checks they
+// are valid and encoded back the same.
+func TestAgentCardGolden(t *testing.T) {
+	files, err := filepath.Glob("testdata/agent_card_*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no golden cards: %v", err)
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			golden, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var card AgentCard
+			dec := json.NewDecoder(bytes.NewReader(golden))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(&card); err != nil {
+				t.Fatalf("decode = %v", err)
+			}
+
+			if err := card.Validate(); err != nil {
+				t.Fatalf("Validate = %v", err)
+			}
+
+			encoded, err := json.Marshal(card)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got, want any
+			json.Unmarshal(encoded, &got)
+			json.Unmarshal(golden, &want)
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("encoded card\n%s\nwant\n%s", encoded, golden)
+			}
+		})
+	}
+}
+
+func TestAgentCardEmptySkills(t *testing.T) {
+	for _, card := range []any{AgentCard{Name: "test"}, &AgentCard{Name: "test"}} {
+		encoded, err := json.Marshal(card)
+		if err != nil || !strings.Contains(string(encoded), `"skills":[]`) || strings.Contains(string(encoded), "authentication") {
+			t.Fatalf("encoded card = %s, %v, want empty skills and no authentication", encoded, err)
+		}
+	}
+}
+
+func TestAgentCardValidate(t *testing.T) {
+	valid := func() AgentCard {
+		return AgentCard{
+			Name:    "test",
+			Url:     "https://agent.example.com",
+			Version: "1.0.0",
+			Skills:  []Skill{{Id: "s1"}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		edit    func(c *AgentCard)
+		wantErr string
+	}{
+		{name: "valid", edit: func(c *AgentCard) {}},
+		{name: "no name", edit: func(c *AgentCard) { c.Name = "" }, wantErr: "name is missing"},
+		{name: "relative url", edit: func(c *AgentCard) { c.Url = "/agent" }, wantErr: "url:"},
+		{name: "no version", edit: func(c *AgentCard) { c.Version = "" }, wantErr: "version is missing"},
+		{name: "no skills", edit: func(c *AgentCard) { c.Skills = nil }, wantErr: "skills are missing"},
+		{name: "skill without id", edit: func(c *AgentCard) { c.Skills[0].Id = "" }, wantErr: "id is missing"},
+		{name: "duplicate skill", edit: func(c *AgentCard) { c.Skills = append(c.Skills, Skill{Id: "s1"}) }, wantErr: "duplicate id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := valid()
+			tt.edit(&card)
+
+			err := card.Validate()
+			if (tt.wantErr == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Validate = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package protocol
+
+import (
+	"encoding/json"
+	"sync/atomic"
+)
+
+// lenient is the decoding mode set by [SetLenientDecoding].
+var lenient atomic.Bool
+
+// SetLenientDecoding makes decoding accept the snake_case field names used by earlier versions
+// of this package (e.g. 'session_id', 'history_length', 'push_notification', 'mime_type')
+// besides the camelCase names of the A2A specification, which win if both are set.
+// It is process wide and meant for migrating peers, encoding always uses the camelCase names.
+// Default is disabled.
+func SetLenientDecoding(enabled bool) {
+	lenient.Store(enabled)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, see [SetLenientDecoding].
+func (t *Task) UnmarshalJSON(data []byte) error {
+	type plain Task
+	if !lenient.Load() {
+		return json.Unmarshal(data, (*plain)(t))
+	}
+
+	v := struct {
+		*plain
+		SessionID *string `json:"session_id"`
+	}{plain: (*plain)(t)}
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	if t.SessionID == "" && v.SessionID != nil {
+		t.SessionID = *v.SessionID
+	}
+
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, see [SetLenientDecoding].
+func (p *TaskSendParams) UnmarshalJSON(data []byte) error {
+	type plain TaskSendParams
+	if !lenient.Load() {
+		return json.Unmarshal(data, (*plain)(p))
+	}
+
+	v := struct {
+		*plain
+		SessionID        *string                 `json:"session_id"`
+		HistoryLength    *int                    `json:"history_length"`
+		PushNotification *PushNotificationConfig `json:"push_notification"`
+	}{plain: (*plain)(p)}
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	p.SessionID = or(p.SessionID, v.SessionID)
+	p.HistoryLength = or(p.HistoryLength, v.HistoryLength)
+	p.PushNotification = or(p.PushNotification, v.PushNotification)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, see [SetLenientDecoding].
+func (a *Artifact) UnmarshalJSON(data []byte) error {
+	type plain Artifact
+	if !lenient.Load() {
+		return json.Unmarshal(data, (*plain)(a))
+	}
+
+	v := struct {
+		*plain
+		LastChunk *bool `json:"last_chunk"`
+	}{plain: (*plain)(a)}
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	a.LastChunk = or(a.LastChunk, v.LastChunk)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, see [SetLenientDecoding].
+func (f *FileContent) UnmarshalJSON(data []byte) error {
+	type plain FileContent
+	if !lenient.Load() {
+		return json.Unmarshal(data, (*plain)(f))
+	}
+
+	v := struct {
+		*plain
+		MimeType *string `json:"mime_type"`
+	}{plain: (*plain)(f)}
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	f.MimeType = or(f.MimeType, v.MimeType)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, see [SetLenientDecoding].
+func (c *TaskPushNotificationConfig) UnmarshalJSON(data []byte) error {
+	type plain TaskPushNotificationConfig
+	if !lenient.Load() {
+		return json.Unmarshal(data, (*plain)(c))
+	}
+
+	v := struct {
+		*plain
+		PushNotificationConfig *PushNotificationConfig `json:"push_notification"`
+	}{plain: (*plain)(c)}
+
+	// decode the legacy config apart, the camelCase one wins if both are set.
+	var camel struct {
+		PushNotificationConfig *json.RawMessage `json:"pushNotificationConfig"`
+	}
+
+	if err := json.Unmarshal(data, &camel); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	if camel.PushNotificationConfig == nil && v.PushNotificationConfig != nil {
+		c.PushNotificationConfig = *v.PushNotificationConfig
+	}
+
+	return nil
+}
+
+// or returns a if set, b otherwise.
+func or[T any](a, b *T) *T {
+	if a != nil {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,127 @@
+package protocol
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestLenientDecoding(t *testing.T) {
+	str := func(s string) *string { return &s }
+	num := func(n int) *int { return &n }
+	yes := func() *bool { v := true; return &v }
+
+	tests := []struct {
+		name    string
+		data    string
+		lenient bool
+		got     any
+		want    any
+	}{
+		{
+			name: "task camelCase", data: `{"id":"t1","sessionId":"s1"}`,
+			got: &Task{}, want: &Task{ID: "t1", SessionID: "s1"},
+		},
+		{
+			name: "task snake_case", data: `{"id":"t1","session_id":"s1"}`, lenient: true,
+			got: &Task{}, want: &Task{ID: "t1", SessionID: "s1"},
+		},
+		{
+			name: "task camelCase wins", data: `{"id":"t1","session_id":"old","sessionId":"s1"}`, lenient: true,
+			got: &Task{}, want: &Task{ID: "t1", SessionID: "s1"},
+		},
+		{
+			name: "task snake_case strict", data: `{"id":"t1","session_id":"s1"}`,
+			got: &Task{}, want: &Task{ID: "t1"},
+		},
+		{
+			name: "task fields added in camelCase only", data: `{"id":"t1","status_history":[{"state":"working"}]}`, lenient: true,
+			got: &Task{}, want: &Task{ID: "t1"},
+		},
+		{
+			name: "send params camelCase", data: `{"id":"t1","sessionId":"s1","historyLength":2,"pushNotification":{"url":"https://a"}}`,
+			got:  &TaskSendParams{},
+			want: &TaskSendParams{ID: "t1", SessionID: str("s1"), HistoryLength: num(2), PushNotification: &PushNotificationConfig{Url: "https://a"}},
+		},
+		{
+			name: "send params snake_case", data: `{"id":"t1","session_id":"s1","history_length":2,"push_notification":{"url":"https://a"}}`, lenient: true,
+			got:  &TaskSendParams{},
+			want: &TaskSendParams{ID: "t1", SessionID: str("s1"), HistoryLength: num(2), PushNotification: &PushNotificationConfig{Url: "https://a"}},
+		},
+		{
+			name: "send params camelCase wins", data: `{"id":"t1","session_id":"old","sessionId":"s1","history_length":5,"historyLength":2}`, lenient: true,
+			got: &TaskSendParams{}, want: &TaskSendParams{ID: "t1", SessionID: str("s1"), HistoryLength: num(2)},
+		},
+		{
+			name: "send params snake_case strict", data: `{"id":"t1","session_id":"s1","history_length":2,"push_notification":{"url":"https://a"}}`,
+			got: &TaskSendParams{}, want: &TaskSendParams{ID: "t1"},
+		},
+		{
+			name: "send params fields added in camelCase only", data: `{"id":"t1","accepted_output_modes":["text"]}`, lenient: true,
+			got: &TaskSendParams{}, want: &TaskSendParams{ID: "t1"},
+		},
+		{
+			name: "artifact snake_case", data: `{"parts":[],"index":1,"last_chunk":true}`, lenient: true,
+			got: &Artifact{}, want: &Artifact{Parts: []Part{}, Index: 1, LastChunk: yes()},
+		},
+		{
+			name: "artifact snake_case strict", data: `{"parts":[],"index":1,"last_chunk":true}`,
+			got: &Artifact{}, want: &Artifact{Parts: []Part{}, Index: 1},
+		},
+		{
+			name: "file snake_case", data: `{"uri":"https://a","mime_type":"text/plain"}`, lenient: true,
+			got: &FileContent{}, want: &FileContent{Uri: str("https://a"), MimeType: str("text/plain")},
+		},
+		{
+			name: "file camelCase wins", data: `{"mime_type":"image/png","mimeType":"text/plain"}`, lenient: true,
+			got: &FileContent{}, want: &FileContent{MimeType: str("text/plain")},
+		},
+		{
+			name: "file snake_case strict", data: `{"mime_type":"text/plain"}`,
+			got: &FileContent{}, want: &FileContent{},
+		},
+		{
+			name: "push config snake_case", data: `{"id":"t1","push_notification":{"url":"https://a"}}`, lenient: true,
+			got:  &TaskPushNotificationConfig{},
+			want: &TaskPushNotificationConfig{ID: "t1", PushNotificationConfig: PushNotificationConfig{Url: "https://a"}},
+		},
+		{
+			name: "push config camelCase wins", data: `{"id":"t1","push_notification":{"url":"https://old"},"pushNotificationConfig":{"url":"https://a"}}`, lenient: true,
+			got:  &TaskPushNotificationConfig{},
+			want: &TaskPushNotificationConfig{ID: "t1", PushNotificationConfig: PushNotificationConfig{Url: "https://a"}},
+		},
+		{
+			name: "push config snake_case strict", data: `{"id":"t1","push_notification":{"url":"https://a"}}`,
+			got: &TaskPushNotificationConfig{}, want: &TaskPushNotificationConfig{ID: "t1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetLenientDecoding(tt.lenient)
+			defer SetLenientDecoding(false)
+
+			if err := json.Unmarshal([]byte(tt.data), tt.got); err != nil {
+				t.Fatalf("Unmarshal = %v", err)
+			}
+
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Fatalf("decoded %+v, want %+v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLenientEncoding(t *testing.T) {
+	SetLenientDecoding(true)
+	defer SetLenientDecoding(false)
+
+	data, err := json.Marshal(TaskSendParams{ID: "t1", SessionID: new(string), HistoryLength: new(int)})
+	if err != nil {
+		t.Fatalf("Marshal = %v", err)
+	}
+
+	if want := `{"id":"t1","sessionId":"","message":{"role":"","parts":null},"historyLength":0}`; string(data) != want {
+		t.Fatalf("encoded %s, want %s", data, want)
+	}
+}
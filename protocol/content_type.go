@@ -0,0 +1,116 @@
+package protocol
+
+import "strings"
+
+// Modes, as in [AgentCard.DefaultInputModes] or [Skill.InputModes], are either mime types,
+// possibly with wildcards (e.g. 'image/*', '*/*'), or part types ('text', 'file', 'data')
+// accepting any part of that type.
+
+// MimeType returns the content type of the part: 'text/plain' for text, 'application/json' for data,
+// the mime type of the file for file, 'application/octet-stream' if the file doesn't tell.
+func (p Part) MimeType() string {
+	switch p.Type {
+	case PartTypeText:
+		return "text/plain"
+	case PartTypeData:
+		return "application/json"
+	}
+
+	if p.File != nil && p.File.MimeType != nil && *p.File.MimeType != "" {
+		return *p.File.MimeType
+	}
+
+	return "application/octet-stream"
+}
+
+// MatchMode reports whether the mode accepts the part. A text part doesn't tell its format,
+// so any 'text/*' mode accepts it, e.g. 'text/markdown'.
+func (p Part) MatchMode(mode string) bool {
+	switch PartType(mode) {
+	case PartTypeText, PartTypeFile, PartTypeData:
+		return p.Type == PartType(mode)
+	}
+
+	mimeType := p.MimeType()
+	if p.Type == PartTypeText {
+		mimeType = "text/*"
+	}
+
+	return matchMimeType(normalizeMode(mode), mimeType)
+}
+
+// AcceptsPart reports whether one of the modes accepts the part, no modes accept everything.
+func AcceptsPart(modes []string, p Part) bool {
+	if len(modes) == 0 {
+		return true
+	}
+
+	for _, mode := range modes {
+		if p.MatchMode(mode) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SelectOutputMode picks the output mode to produce: the first of the modes accepted by the client
+// that the agent offers, in the order of the client preference. Of two matching modes,
+// the most specific is returned, e.g. 'image/png' for 'image/*' accepted and 'image/png' offered.
+// No modes on either side accept everything, false is returned if no mode matches.
+func SelectOutputMode(accepted, offered []string) (string, bool) {
+	switch {
+	case len(offered) == 0 && len(accepted) == 0:
+		return "", true
+	case len(accepted) == 0:
+		return offered[0], true
+	case len(offered) == 0:
+		return accepted[0], true
+	}
+
+	for _, a := range accepted {
+		for _, o := range offered {
+			na, no := normalizeMode(a), normalizeMode(o)
+			if !matchMimeType(na, no) {
+				continue
+			}
+
+			if strings.Count(na, "*") < strings.Count(no, "*") {
+				return a, true
+			}
+
+			return o, true
+		}
+	}
+
+	return "", false
+}
+
+// normalizeMode returns the mode as a mime type without parameters, part types are mapped
+// to the mime types of their parts.
+func normalizeMode(mode string) string {
+	switch PartType(mode) {
+	case PartTypeText:
+		return "text/*"
+	case PartTypeData:
+		return "application/json"
+	case PartTypeFile:
+		return "*/*"
+	}
+
+	mode, _, _ = strings.Cut(mode, ";")
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode == "*" {
+		return "*/*"
+	}
+
+	return mode
+}
+
+// matchMimeType reports whether the mime types overlap, either may have wildcards.
+func matchMimeType(a, b string) bool {
+	at, as, _ := strings.Cut(a, "/")
+	bt, bs, _ := strings.Cut(b, "/")
+
+	return (at == "*" || bt == "*" || at == bt) && (as == "*" || bs == "*" || as == bs)
+}
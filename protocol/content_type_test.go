@@ -0,0 +1,58 @@
+package protocol
+
+import "testing"
+
+func TestAcceptsPart(t *testing.T) {
+	png := "image/png"
+	image := NewFilePart(FileContent{MimeType: &png})
+
+	tests := []struct {
+		name  string
+		modes []string
+		part  Part
+		want  bool
+	}{
+		{name: "no modes", part: NewTextPart("hi"), want: true},
+		{name: "text/plain text", modes: []string{"text/plain"}, part: NewTextPart("hi"), want: true},
+		{name: "text/markdown text", modes: []string{"text/markdown"}, part: NewTextPart("hi"), want: true},
+		{name: "text/html text", modes: []string{"text/html; charset=utf-8"}, part: NewTextPart("hi"), want: true},
+		{name: "text part type", modes: []string{"text"}, part: NewTextPart("hi"), want: true},
+		{name: "text rejected by image modes", modes: []string{"image/*"}, part: NewTextPart("hi"), want: false},
+		{name: "image/* file", modes: []string{"image/*"}, part: image, want: true},
+		{name: "file rejected by text modes", modes: []string{"text/*"}, part: image, want: false},
+		{name: "json data", modes: []string{"application/json"}, part: Part{Type: PartTypeData}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AcceptsPart(tt.modes, tt.part); got != tt.want {
+				t.Fatalf("AcceptsPart(%v) = %v, want %v", tt.modes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectOutputMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		accepted []string
+		offered  []string
+		want     string
+		ok       bool
+	}{
+		{name: "none", ok: true},
+		{name: "first offered", offered: []string{"text/plain", "image/png"}, want: "text/plain", ok: true},
+		{name: "most specific", accepted: []string{"image/*"}, offered: []string{"image/png"}, want: "image/png", ok: true},
+		{name: "client preference", accepted: []string{"image/png", "text/plain"}, offered: []string{"text/plain", "image/png"}, want: "image/png", ok: true},
+		{name: "no match", accepted: []string{"audio/*"}, offered: []string{"text/plain"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := SelectOutputMode(tt.accepted, tt.offered)
+			if got != tt.want || ok != tt.ok {
+				t.Fatalf("SelectOutputMode = %q, %v, want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -13,6 +13,9 @@ const (
 	CodePushNotificationNotSupport = -32003
 	CodeUnsupportedOperation       = -32004
 	CodeIncompatibleContentTypes   = -32005
+
+	// CodeServerBusy is an implementation defined server error, see [ServerBusyData].
+	CodeServerBusy = -32010
 )
 
 // Etyp is the shortcut for [NewErrorType].
@@ -29,16 +32,81 @@ var (
 	// Args: [client version], [supported version]
 	ErrInvalidVersion = Etyp(CodeInvalidRequest, "Invalid JSON-RPC version: [%s], expected [%s]")
 
+	// ErrTaskRunning
+	// Args: [task id]
+	ErrTaskRunning = Etyp(CodeInvalidRequest, "Task [%s] is running")
+
+	// ErrTaskTerminated
+	// Args: [task id], [task state]
+	ErrTaskTerminated = Etyp(CodeInvalidRequest, "Task [%s] is already in terminal state [%s]")
+
 	// MethodNotFound errors.
 	// Args: [request method]
 	ErrMethodNotFound = Etyp(CodeMethodNotFound, "Method [%s] not found")
 
+	// InvalidParams errors.
+
+	// ErrPushNotificationURL
+	// Args: [url], [reason]
+	ErrPushNotificationURL = Etyp(CodeInvalidParams, "Push notification url [%s] is not allowed: %s")
+
+	// ErrPushNotificationNotFound
+	// Args: [task id]
+	ErrPushNotificationNotFound = Etyp(CodeInvalidParams, "Push notification of task [%s] not configured")
+
+	// ErrSessionNotFound
+	// Args: [session id]
+	ErrSessionNotFound = Etyp(CodeInvalidParams, "Session [%s] not found")
+
+	// ErrInvalidCursor
+	// Args: [cursor]
+	ErrInvalidCursor = Etyp(CodeInvalidParams, "Invalid cursor [%s]")
+
+	// ErrSkillNotFound
+	// Args: [skill id]
+	ErrSkillNotFound = Etyp(CodeInvalidParams, "Skill [%s] not found")
+
+	// TaskNotFound errors.
+	// Args: [task id]
+	ErrTaskNotFound = Etyp(CodeTaskNotFound, "Task [%s] not found")
+
+	// TaskCannotCancel errors.
+	// Args: [task id], [task state]
+	ErrTaskCannotCancel = Etyp(CodeTaskCannotCancel, "Task [%s] cannot be canceled in state [%s]")
+
+	// PushNotificationNotSupport errors.
+	ErrPushNotificationNotSupported = Etyp(CodePushNotificationNotSupport, "Push notification is not supported")
+
+	// UnsupportedOperation errors.
+	// Args: [request method]
+	ErrUnsupportedOperation = Etyp(CodeUnsupportedOperation, "Operation [%s] is not supported")
+
+	// IncompatibleContentTypes errors, carrying [ContentTypesData].
+	// Args: [reason]
+	ErrIncompatibleContentTypes = Etyp(CodeIncompatibleContentTypes, "Incompatible content types: %s")
+
+	// ServerBusy errors, carrying [ServerBusyData].
+	// Args: [retry after]
+	ErrServerBusy = Etyp(CodeServerBusy, "Server is busy, retry after %s")
+
 	// CodeInternalError errors.
 	// Args: [error message]
 	ErrInternalError = Etyp(CodeInternalError, "Internal error occurred: [%s]")
 )
 
 type (
+	// ServerBusyData is the data of [ErrServerBusy].
+	ServerBusyData struct {
+		// Seconds to wait before retrying.
+		RetryAfter int `json:"retryAfter"`
+	}
+
+	// ContentTypesData is the data of [ErrIncompatibleContentTypes], the modes supported.
+	ContentTypesData struct {
+		InputModes  []string `json:"inputModes,omitempty"`
+		OutputModes []string `json:"outputModes,omitempty"`
+	}
+
 	ErrorType struct {
 		code   int
 		format string
@@ -85,7 +153,7 @@ func (e *Error) ToJsonRpc(id uint64) *JsonRpcResponse {
 		ID:             id,
 		Error: &JsonRpcError{
 			Code:    e.code,
-			Message: e.message,
+			Message: e.Error(),
 			Data:    e.data,
 		},
 	}
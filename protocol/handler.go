@@ -10,6 +10,14 @@ const (
 	MethodGetTaskPushNotifications A2AMethod = "tasks/pushNotification/get"
 	MethodSubscribeTask            A2AMethod = "tasks/sendSubscribe"
 	MethodResubscribeTask          A2AMethod = "tasks/resubscribe"
+
+	// Extension methods, not part of the A2A specification.
+
+	// MethodListSessionTasks lists the tasks of a session, see [SessionTasksParams].
+	MethodListSessionTasks A2AMethod = "sessions/tasks"
+
+	// MethodListTasks lists the tasks of the agent visible to the caller, see [ListTasksParams].
+	MethodListTasks A2AMethod = "tasks/list"
 )
 
 type A2AMethod string
@@ -37,3 +45,9 @@ type IA2AProtocol interface {
 
 	ResubscribeTask(ctx context.Context, params *TaskSendParams) (chan any, error)
 }
+
+// ITaskLister is implemented by the [IA2AProtocol] handlers able to list their tasks, see [MethodListTasks].
+// Only the tasks visible to the caller of ctx are listed.
+type ITaskLister interface {
+	ListTasks(ctx context.Context, params *ListTasksParams) (*ListTasksResult, error)
+}
@@ -0,0 +1,20 @@
+package protocol
+
+// Push notification transport conventions shared by the agent that sends
+// notifications and the client that receives them.
+const (
+	// HeaderNotificationToken carries [PushNotificationConfig.Token] on every notification request,
+	// so the receiver can check that the notification belongs to a task it configured.
+	HeaderNotificationToken = "X-A2A-Notification-Token"
+
+	// HeaderNotificationID uniquely identifies a single notification.
+	// Retries of the same notification must reuse the same value so the receiver can deduplicate them.
+	HeaderNotificationID = "X-A2A-Notification-Id"
+
+	// QueryValidationToken is the query parameter of the GET request an agent issues to verify
+	// that it is allowed to send notifications to a URL. The receiver echoes the value back in the body.
+	QueryValidationToken = "validationToken"
+
+	// ClaimRequestBodySHA256 is the JWT claim holding the hex encoded SHA-256 of the notification body.
+	ClaimRequestBodySHA256 = "request_body_sha256"
+)
@@ -1,5 +1,7 @@
 package protocol
 
+import "time"
+
 const (
 	TaskStateSubmitted     TaskState = "submitted"
 	TaskStateWorking       TaskState = "working"
@@ -11,14 +13,51 @@ const (
 
 	RoleUser  Role = "user"
 	RoleAgent Role = "agent"
+
+	PartTypeText PartType = "text"
+	PartTypeFile PartType = "file"
+	PartTypeData PartType = "data"
+
+	// MetadataLastEventID is the [TaskSendParams.Metadata] key carrying the id of the last event
+	// received, as an alternative to the 'Last-Event-ID' header of tasks/resubscribe.
+	MetadataLastEventID = "lastEventId"
+
+	// MetadataPriority is the [TaskSendParams.Metadata] key carrying the priority of the task,
+	// an integer, higher is scheduled first when the server is queueing tasks. Default is 0.
+	MetadataPriority = "priority"
+
+	// MetadataSkillID is the [TaskSendParams.Metadata] key carrying the id of the skill requested.
+	MetadataSkillID = "skillId"
+
+	// MetadataBlocking is the [TaskSendParams.Metadata] key choosing whether tasks/send waits
+	// for the task to reach a final state, a boolean. false returns the task as soon as it is submitted.
+	MetadataBlocking = "blocking"
+
+	// MetadataBlockingTimeout is the [TaskSendParams.Metadata] key carrying the maximum time
+	// a blocking tasks/send waits, in milliseconds, before returning the task as it is.
+	MetadataBlockingTimeout = "blockingTimeout"
+
+	// MetadataIdempotencyKey is the [TaskSendParams.Metadata] key carrying a key identifying
+	// the message sent, so the server recognizes a retried tasks/send. Without key, a send is only
+	// recognized as a retry by a server comparing the messages, if enabled.
+	MetadataIdempotencyKey = "idempotencyKey"
 )
 
+// TimestampFormat is the ISO 8601 layout of [TaskStatus.Timestamp].
+const TimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
 // Enum type.
 type (
 	TaskState string
 	Role      string
+	PartType  string
 )
 
+// IsTerminal reports whether no more updates of a task in this state are expected.
+func (s TaskState) IsTerminal() bool {
+	return s == TaskStateCompleted || s == TaskStateCanceled || s == TaskStateFailed
+}
+
 // Task.
 type (
 	Task struct {
@@ -26,10 +65,14 @@ type (
 		ID string `json:"id"`
 
 		// Client-generated id for the session holding the task.
-		SessionID string `json:"session_id"`
+		SessionID string `json:"sessionId"`
 
 		// Current status of the task.
-		Status string `json:"status"`
+		Status TaskStatus `json:"status"`
+
+		// Status changes of the task, oldest first, ending with the current status.
+		// Only set by agents with the StateTransitionHistory capability.
+		StatusHistory []TaskStatus `json:"statusHistory,omitempty"`
 
 		// History of messages exchanged between the agent and the client.
 		History []Message `json:"history,omitempty"`
@@ -50,7 +93,7 @@ type (
 		// Additional status updates for client.
 		Message *Message `json:"message,omitempty"`
 
-		// ISO datetime value.
+		// ISO datetime value, see [TimestampFormat].
 		Timestamp *string `json:"timestamp,omitempty"`
 	}
 
@@ -85,16 +128,19 @@ type (
 		ID string `json:"id"`
 
 		// Server creates a new sessionId for new tasks if not set.
-		SessionID *string `json:"session_id,omitempty"`
+		SessionID *string `json:"sessionId,omitempty"`
 
 		// Message to send to the agent.
 		Message Message `json:"message"`
 
 		// Number of recent messages to be retrieved.
-		HistoryLength *int `json:"history_length,omitempty"`
+		HistoryLength *int `json:"historyLength,omitempty"`
+
+		// Output modes the client accepts, see [SelectOutputMode]. Any if empty.
+		AcceptedOutputModes []string `json:"acceptedOutputModes,omitempty"`
 
 		// Where the server should send notifications when disconnected.
-		PushNotification *PushNotificationConfig `json:"push_notification,omitempty"`
+		PushNotification *PushNotificationConfig `json:"pushNotification,omitempty"`
 
 		// Extension metadata.
 		Metadata map[string]any `json:"metadata,omitempty"`
@@ -117,7 +163,7 @@ type Artifact struct {
 
 	Index     int   `json:"index"`
 	Append    *bool `json:"append,omitempty"`
-	LastChunk *bool `json:"last_chunk,omitempty"`
+	LastChunk *bool `json:"lastChunk,omitempty"`
 }
 
 // Message contains any content that is not an Artifact.
@@ -142,35 +188,88 @@ type (
 
 	// A fully formed piece of content exchanged between a client and a remote agent as part of a Message or an Artifact.
 	// Each Part has its own content type and metadata.
+	//
+	// Only the content field matching Type is set:
+	//   - "text": Text
+	//   - "file": File
+	//   - "data": Data
 	Part struct {
-		Type string `json:"type"`
+		Type PartType `json:"type"`
+
+		Text string `json:"text,omitempty"`
+
+		File *FileContent `json:"file,omitempty"`
+
+		Data map[string]any `json:"data,omitempty"`
 
 		// Extension metadata.
 		Metadata map[string]any `json:"metadata,omitempty"`
 	}
 
-	TextPart struct {
-		Part `json:",inline"`
-		Text string `json:"text"`
+	// Content of a file part, either Bytes or Uri is set.
+	FileContent struct {
+		Name     *string `json:"name,omitempty"`
+		MimeType *string `json:"mimeType,omitempty"`
+
+		// Base64 encoded content.
+		Bytes *string `json:"bytes,omitempty"`
+		Uri   *string `json:"uri,omitempty"`
 	}
+)
+
+func NewTextPart(text string) Part {
+	return Part{Type: PartTypeText, Text: text}
+}
 
-	FilePart struct {
-		Part `json:",inline"`
-		File struct {
-			Name     *string `json:"name,omitempty"`
-			MimeType *string `json:"mime_type,omitempty"`
+func NewFilePart(file FileContent) Part {
+	return Part{Type: PartTypeFile, File: &file}
+}
 
-			// Base64 encoded content.
-			Bytes *string `json:"bytes,omitempty"`
-			Uri   *string `json:"uri,omitempty"`
-		} `json:"file"`
-	}
+func NewDataPart(data map[string]any) Part {
+	return Part{Type: PartTypeData, Data: data}
+}
 
-	DataPart struct {
-		Part `json:",inline"`
-		Data map[string]any `json:"data,omitempty"`
-	}
-)
+// Sent by the client to list the tasks of a session, see [MethodListSessionTasks].
+type SessionTasksParams struct {
+	SessionID string `json:"sessionId"`
+
+	// Number of recent messages of each task to be retrieved.
+	HistoryLength *int `json:"historyLength,omitempty"`
+}
+
+// Sent by the client to list the tasks of the agent, see [MethodListTasks].
+// Every filter set must match, tasks are listed the most recently updated first.
+type ListTasksParams struct {
+	// Only the tasks of the session.
+	SessionID *string `json:"sessionId,omitempty"`
+
+	// Only the tasks in one of the states.
+	States []TaskState `json:"states,omitempty"`
+
+	// Only the tasks whose status was last updated in the range, bounds included.
+	UpdatedAfter  *time.Time `json:"updatedAfter,omitempty"`
+	UpdatedBefore *time.Time `json:"updatedBefore,omitempty"`
+
+	// Only the tasks having every key in their metadata, with the same value unless null.
+	Metadata map[string]any `json:"metadata,omitempty"`
+
+	// Maximum number of tasks returned. Default is 50, at most 100.
+	PageSize *int `json:"pageSize,omitempty"`
+
+	// Cursor returned by the previous page, empty for the first page.
+	Cursor string `json:"cursor,omitempty"`
+
+	// Number of recent messages of each task to be retrieved.
+	HistoryLength *int `json:"historyLength,omitempty"`
+}
+
+// A page of tasks, see [MethodListTasks].
+type ListTasksResult struct {
+	Tasks []*Task `json:"tasks"`
+
+	// Cursor of the next page, empty if this is the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
 
 type (
 	PushNotificationConfig struct {
@@ -181,6 +280,6 @@ type (
 
 	TaskPushNotificationConfig struct {
 		ID                     string                 `json:"id"`
-		PushNotificationConfig PushNotificationConfig `json:"push_notification"`
+		PushNotificationConfig PushNotificationConfig `json:"pushNotificationConfig"`
 	}
 )
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/internal/jws"
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+type (
+	// cardDocument is the agent card as served, rebuilt only when the card changes.
+	cardDocument struct {
+		card     protocol.AgentCard
+		body     []byte
+		etag     string
+		modified time.Time
+
+		// detached JWS of body, empty if the card is not signed.
+		signature string
+	}
+
+	cardSigner struct {
+		alg string
+		kid string
+		key any
+	}
+)
+
+// cardDocument returns the served document of the public agent card, rebuilding it if the card changed.
+func (s *A2AServer) cardDocument() (*cardDocument, error) {
+	card := s.AgentCard()
+	if s.extendedCard != nil {
+		card.SupportsAuthenticatedExtendedCard = true
+	}
+
+	s.cardMu.Lock()
+	defer s.cardMu.Unlock()
+
+	if s.cardDoc != nil && reflect.DeepEqual(s.cardDoc.card, card) {
+		return s.cardDoc, nil
+	}
+
+	doc, err := s.newCardDocument(card)
+	if err != nil {
+		return nil, err
+	}
+
+	// a card marshaled the same, e.g. after a reload, keeps its validators.
+	if s.cardDoc != nil && bytes.Equal(s.cardDoc.body, doc.body) {
+		s.cardDoc.card = card
+		return s.cardDoc, nil
+	}
+
+	doc.modified = time.Now()
+	s.cardDoc = doc
+	return doc, nil
+}
+
+// newCardDocument marshals and signs the card.
+func (s *A2AServer) newCardDocument(card protocol.AgentCard) (*cardDocument, error) {
+	body, err := json.Marshal(card)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(body)
+	doc := &cardDocument{
+		card: card,
+		body: body,
+		etag: `"` + hex.EncodeToString(sum[:16]) + `"`,
+	}
+
+	if s.cardSigner != nil {
+		doc.signature, err = jws.SignDetached(s.cardSigner.alg, s.cardSigner.kid, s.cardSigner.key, body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+// cardHandler serves the agent card, answering conditional requests with 304.
+// Authenticated principals are served their extended card, see [WithExtendedCard].
+type cardHandler struct {
+	server       *A2AServer
+	cacheControl string
+	auth         Authenticator
+}
+
+// ServeHTTP implements http.Handler.
+func (h *cardHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, ok := authenticate(w, req, h.auth)
+	if !ok {
+		return
+	}
+
+	var (
+		doc          *cardDocument
+		err          error
+		cacheControl = h.cacheControl
+	)
+
+	if card, extended := h.server.extendedCardFor(req.Context()); extended {
+		// extended cards are never stored by shared caches, nor validated by date.
+		cacheControl = "private, no-cache"
+		doc, err = h.server.newCardDocument(card)
+	} else {
+		doc, err = h.server.cardDocument()
+	}
+
+	if err != nil {
+		http.Error(w, "agent card unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "application/json")
+	header.Set("ETag", doc.etag)
+	if cacheControl != "" {
+		header.Set("Cache-Control", cacheControl)
+	}
+
+	if h.auth != nil {
+		header.Set("Vary", "Authorization")
+	}
+
+	if doc.signature != "" {
+		header.Set(protocol.HeaderCardSignature, doc.signature)
+	}
+
+	// ServeContent answers If-None-Match and If-Modified-Since.
+	http.ServeContent(w, req, "", doc.modified, bytes.NewReader(doc.body))
+}
+
+var _ http.Handler = (*cardHandler)(nil)
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zhengrenjie/go-a2a/internal/jws"
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+// getCard fetches the agent card served by h, with the ETag if any.
+func getCard(t *testing.T, h http.Handler, etag string) (*http.Response, []byte) {
+	t.Helper()
+
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/.well-known/agent.json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET = %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp, body
+}
+
+func TestCardHandlerCaching(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []HostOption
+		want string
+	}{
+		{name: "default cache control", want: "public, max-age=300"},
+		{name: "custom cache control", opts: []HostOption{WithCardCacheControl("no-store")}, want: "no-store"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := protocol.AgentCard{Name: "test", Url: "http://localhost", Version: "1.0.0"}
+			s := NewA2AServerWithExecutor(card, AgentExecutorFunc(
+				func(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+					return updater.Complete(ctx)
+				}))
+
+			h, _ := NewA2AHost(":0", tt.opts...).handlers(s)
+
+			resp, body := getCard(t, h, "")
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+
+			var served protocol.AgentCard
+			if err := json.Unmarshal(body, &served); err != nil || served.Name != card.Name {
+				t.Fatalf("served card %s: %v", body, err)
+			}
+
+			if got := resp.Header.Get("Cache-Control"); got != tt.want {
+				t.Fatalf("Cache-Control = %q, want %q", got, tt.want)
+			}
+
+			etag := resp.Header.Get("ETag")
+			if len(etag) < 3 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+				t.Fatalf("ETag = %q, want a strong validator", etag)
+			}
+
+			// the same card keeps its ETag.
+			if again, _ := getCard(t, h, ""); again.Header.Get("ETag") != etag {
+				t.Fatalf("ETag = %q, then %q", etag, again.Header.Get("ETag"))
+			}
+
+			resp, body = getCard(t, h, etag)
+			if resp.StatusCode != http.StatusNotModified || len(body) != 0 {
+				t.Fatalf("If-None-Match = %d with %d bytes, want 304 without body", resp.StatusCode, len(body))
+			}
+
+			if resp, _ = getCard(t, h, `"stale"`); resp.StatusCode != http.StatusOK {
+				t.Fatalf("stale If-None-Match = %d, want 200", resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestCardHandlerSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	card := protocol.AgentCard{Name: "test", Url: "http://localhost", Version: "1.0.0"}
+	s := NewA2AServerWithExecutor(card, AgentExecutorFunc(
+		func(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+			return updater.Complete(ctx)
+		}), WithCardSigning(jws.AlgEdDSA, "k1", priv))
+
+	h, _ := NewA2AHost(":0").handlers(s)
+	resp, body := getCard(t, h, "")
+
+	signature := resp.Header.Get(protocol.HeaderCardSignature)
+	if signature == "" {
+		t.Fatalf("no %s header", protocol.HeaderCardSignature)
+	}
+
+	// the signature covers the exact bytes served.
+	token, err := jws.ParseDetached(signature, body)
+	if err != nil {
+		t.Fatalf("ParseDetached = %v", err)
+	}
+
+	if token.Header.Alg != jws.AlgEdDSA || token.Header.Kid != "k1" {
+		t.Fatalf("header = %+v, want EdDSA k1", token.Header)
+	}
+
+	if err := token.Verify(pub); err != nil {
+		t.Fatalf("Verify = %v", err)
+	}
+
+	tampered := append([]byte(nil), body...)
+	tampered[len(tampered)-2] ^= 1
+	if token, err := jws.ParseDetached(signature, tampered); err == nil && token.Verify(pub) == nil {
+		t.Fatal("signature verified a tampered card")
+	}
+}
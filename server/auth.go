@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+type (
+	// Principal is the authenticated caller of a request.
+	Principal struct {
+		// Subject identifies the caller, e.g. the 'sub' claim of a token.
+		Subject string
+
+		// Scopes granted to the caller.
+		Scopes []string
+
+		// Claims are the other attributes of the caller, e.g. its tenant.
+		Claims map[string]any
+	}
+
+	// Authenticator returns the principal of the request, nil if anonymous.
+	// An error rejects the request with 401, e.g. for invalid credentials.
+	Authenticator func(req *http.Request) (*Principal, error)
+
+	// ExtendedCardFunc returns the agent card of an authenticated principal, e.g. with
+	// partner-only skills or internal urls. ok is false to serve the public card.
+	ExtendedCardFunc func(ctx context.Context, principal *Principal, public protocol.AgentCard) (card protocol.AgentCard, ok bool)
+
+	principalKey struct{}
+	cardKey      struct{}
+)
+
+// ContextWithPrincipal returns a copy of ctx carrying the principal.
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal of the request, set by the [Authenticator] of the host.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// subject returns the subject of the principal of ctx, empty if anonymous.
+func subject(ctx context.Context) string {
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		return principal.Subject
+	}
+
+	return ""
+}
+
+// HasScope reports whether the principal was granted the scope.
+func (p *Principal) HasScope(scope string) bool {
+	return p != nil && slices.Contains(p.Scopes, scope)
+}
+
+// AgentCardFor returns the agent card of the caller of ctx: its extended card if authenticated
+// and one is set by [WithExtendedCard], the public card otherwise.
+func (s *A2AServer) AgentCardFor(ctx context.Context) protocol.AgentCard {
+	card, _ := s.extendedCardFor(ctx)
+	return card
+}
+
+// AgentCardFromContext returns the agent card of the caller who sent the task being executed
+// by an [AgentExecutor], see [A2AServer.AgentCardFor].
+func AgentCardFromContext(ctx context.Context) (*protocol.AgentCard, bool) {
+	card, ok := ctx.Value(cardKey{}).(*protocol.AgentCard)
+	return card, ok
+}
+
+func withAgentCard(ctx context.Context, card protocol.AgentCard) context.Context {
+	return context.WithValue(ctx, cardKey{}, &card)
+}
+
+// extendedCardFor returns the agent card of the caller of ctx, and whether it is an extended card.
+func (s *A2AServer) extendedCardFor(ctx context.Context) (protocol.AgentCard, bool) {
+	public := s.AgentCard()
+
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || s.extendedCard == nil {
+		return public, false
+	}
+
+	card, ok := s.extendedCard(ctx, principal, public)
+	if !ok {
+		return public, false
+	}
+
+	return card, true
+}
+
+// authenticate returns the request carrying its principal, writing 401 and returning false
+// if the authenticator rejects it.
+func authenticate(w http.ResponseWriter, req *http.Request, auth Authenticator) (*http.Request, bool) {
+	if auth == nil {
+		return req, true
+	}
+
+	principal, err := auth(req)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return nil, false
+	}
+
+	if principal == nil {
+		return req, true
+	}
+
+	return req.WithContext(ContextWithPrincipal(req.Context(), principal)), true
+}
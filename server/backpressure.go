@@ -0,0 +1,242 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+const (
+	// BackpressureBlock blocks the producer until the client catches up.
+	// If BlockTimeout is set and elapses first, the client is disconnected.
+	// As the producer is blocked, the next events of the task are delayed for every stream.
+	BackpressureBlock BackpressureMode = iota
+
+	// BackpressureDropStatus drops the oldest intermediate status event to make room,
+	// final status events are always kept. Falls back to BackpressureBlock if there is none.
+	BackpressureDropStatus
+
+	// BackpressureCoalesceArtifacts merges an appended artifact chunk into the previous chunk
+	// of the same artifact waiting in the buffer. Falls back to BackpressureBlock if there is none.
+	BackpressureCoalesceArtifacts
+
+	// BackpressureDisconnect disconnects the client as soon as the buffer is full.
+	BackpressureDisconnect
+)
+
+type (
+	BackpressureMode int
+
+	// BackpressurePolicy decides what happens to a stream whose client doesn't keep up with the events.
+	// Every stream buffers its events independently, an event is delivered to the other streams
+	// while a slow client is blocking. The producer however waits for every stream to take the event,
+	// so in the blocking modes a slow client delays the next events of the task, up to BlockTimeout.
+	//
+	// A disconnected client can resubscribe with the id of the last event received
+	// to replay what it missed, dropped and coalesced events are still in the event log.
+	BackpressurePolicy struct {
+		Mode BackpressureMode
+
+		// Number of events buffered per stream before the policy applies. Default is 16.
+		BufferSize int
+
+		// How long the producer blocks before disconnecting the client, zero blocks until the client leaves.
+		BlockTimeout time.Duration
+	}
+
+	// streamQueue is the buffer of a single stream applying the backpressure policy.
+	streamQueue struct {
+		policy BackpressurePolicy
+
+		mu     sync.Mutex
+		items  []loggedEvent
+		closed bool
+
+		// signaled when an item is added or the queue is closed.
+		ready chan struct{}
+
+		// signaled when an item is removed.
+		space chan struct{}
+	}
+)
+
+func newStreamQueue(policy BackpressurePolicy) *streamQueue {
+	if policy.BufferSize <= 0 {
+		policy.BufferSize = 16
+	}
+
+	return &streamQueue{
+		policy: policy,
+		ready:  make(chan struct{}, 1),
+		space:  make(chan struct{}, 1),
+	}
+}
+
+// push adds the event, applying the policy if the buffer is full.
+// It returns early if done is closed.
+func (q *streamQueue) push(e loggedEvent, done <-chan struct{}) {
+	var timeout <-chan time.Time
+
+	q.mu.Lock()
+	for {
+		if q.closed {
+			q.mu.Unlock()
+			return
+		}
+
+		// final events are never dropped, even beyond the buffer size.
+		if len(q.items) < q.policy.BufferSize || isFinal(e.event) {
+			q.items = append(q.items, e)
+			q.mu.Unlock()
+			signal(q.ready)
+			return
+		}
+
+		switch q.policy.Mode {
+		case BackpressureDropStatus:
+			if q.dropStatus() {
+				q.items = append(q.items, e)
+				q.mu.Unlock()
+				signal(q.ready)
+				return
+			}
+		case BackpressureCoalesceArtifacts:
+			if q.coalesce(e) {
+				q.mu.Unlock()
+				signal(q.ready)
+				return
+			}
+		case BackpressureDisconnect:
+			q.disconnect()
+			q.mu.Unlock()
+			return
+		}
+
+		q.mu.Unlock()
+
+		if timeout == nil && q.policy.BlockTimeout > 0 {
+			timer := time.NewTimer(q.policy.BlockTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+
+		select {
+		case <-q.space:
+		case <-done:
+			return
+		case <-timeout:
+			q.mu.Lock()
+			q.disconnect()
+			q.mu.Unlock()
+			return
+		}
+
+		q.mu.Lock()
+	}
+}
+
+// next returns the next event, false once the queue is closed and drained or ctx is done.
+func (q *streamQueue) next(ctx context.Context) (loggedEvent, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			e := q.items[0]
+			q.items[0] = loggedEvent{}
+			q.items = q.items[1:]
+			q.mu.Unlock()
+
+			signal(q.space)
+			return e, true
+		}
+
+		closed := q.closed
+		q.mu.Unlock()
+
+		if closed {
+			return loggedEvent{}, false
+		}
+
+		select {
+		case <-q.ready:
+		case <-ctx.Done():
+			return loggedEvent{}, false
+		}
+	}
+}
+
+// close ends the stream once the buffered events are consumed.
+func (q *streamQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+
+	signal(q.ready)
+}
+
+// disconnect drops the buffered events and ends the stream, must be called with q.mu held.
+func (q *streamQueue) disconnect() {
+	q.items = nil
+	q.closed = true
+	signal(q.ready)
+}
+
+// dropStatus removes the oldest intermediate status event, must be called with q.mu held.
+func (q *streamQueue) dropStatus() bool {
+	for i, item := range q.items {
+		if isStatus(item.event) && !isFinal(item.event) {
+			q.items = append(q.items[:i], q.items[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// coalesce merges e into the last buffered event if both are chunks of the same artifact,
+// must be called with q.mu held.
+func (q *streamQueue) coalesce(e loggedEvent) bool {
+	next, ok := e.event.(*protocol.TaskArtifactUpdateEvent)
+	if !ok || next == nil || next.Artifact.Append == nil || !*next.Artifact.Append {
+		return false
+	}
+
+	last := &q.items[len(q.items)-1]
+	prev, ok := last.event.(*protocol.TaskArtifactUpdateEvent)
+	if !ok || prev == nil || prev.ID != next.ID || prev.Artifact.Index != next.Artifact.Index {
+		return false
+	}
+
+	if prev.Artifact.LastChunk != nil && *prev.Artifact.LastChunk {
+		return false
+	}
+
+	// events are shared with the event log and other streams, merge into a copy.
+	merged := *prev
+	merged.Artifact.Parts = append(append([]protocol.Part{}, prev.Artifact.Parts...), next.Artifact.Parts...)
+	merged.Artifact.LastChunk = next.Artifact.LastChunk
+
+	// the merged event carries the id of the latest chunk, so Last-Event-ID resumes after it.
+	last.id = e.id
+	last.seq = e.seq
+	last.event = &merged
+	return true
+}
+
+func isStatus(event any) bool {
+	switch event.(type) {
+	case *protocol.TaskStatusUpdateEvent, protocol.TaskStatusUpdateEvent:
+		return true
+	}
+
+	return false
+}
+
+// signal wakes up the waiter of ch without blocking, ch must have a buffer of 1.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
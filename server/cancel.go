@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"sync"
+)
+
+// cancelRegistry keeps the cancel functions of the in-flight handler calls of each task,
+// so tasks/cancel reaches the work started by tasks/send and tasks/sendSubscribe.
+type cancelRegistry struct {
+	mu    sync.Mutex
+	seq   uint64
+	tasks map[string]map[uint64]context.CancelFunc
+}
+
+func newCancelRegistry() *cancelRegistry {
+	return &cancelRegistry{
+		tasks: make(map[string]map[uint64]context.CancelFunc),
+	}
+}
+
+// track returns a cancelable ctx for a handler call working on the task.
+// The returned function must be called once the work is done.
+func (r *cancelRegistry) track(ctx context.Context, taskID string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq++
+	id := r.seq
+	if r.tasks[taskID] == nil {
+		r.tasks[taskID] = make(map[uint64]context.CancelFunc)
+	}
+	r.tasks[taskID][id] = cancel
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			delete(r.tasks[taskID], id)
+			if len(r.tasks[taskID]) == 0 {
+				delete(r.tasks, taskID)
+			}
+
+			cancel()
+		})
+	}
+}
+
+// cancel cancels every in-flight handler call working on the task.
+func (r *cancelRegistry) cancel(taskID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, cancel := range r.tasks[taskID] {
+		cancel()
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+// cancelHandler answers tasks/cancel with the task in state, no task if state is empty.
+type cancelHandler struct {
+	protocol.IA2AProtocol
+	state protocol.TaskState
+}
+
+func (h cancelHandler) AgentCard() protocol.AgentCard {
+	return protocol.AgentCard{Name: "test"}
+}
+
+func (h cancelHandler) CancelTask(ctx context.Context, params *protocol.TaskSendParams) (*protocol.Task, error) {
+	if h.state == "" {
+		return nil, nil
+	}
+
+	return &protocol.Task{ID: params.ID, Status: protocol.TaskStatus{State: h.state}}, nil
+}
+
+func TestCancelState(t *testing.T) {
+	tests := []struct {
+		state     protocol.TaskState
+		wantCode  int
+		wantFinal bool
+	}{
+		{state: protocol.TaskStateCanceled, wantFinal: true},
+		{state: protocol.TaskStateCompleted, wantCode: protocol.CodeTaskCannotCancel},
+		{state: protocol.TaskStateWorking, wantCode: protocol.CodeTaskCannotCancel},
+		{state: "", wantCode: protocol.CodeInternalError},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.state), func(t *testing.T) {
+			s := NewA2AServer(cancelHandler{state: tt.state})
+			_, sub, _ := s.events.subscribe("t1", "", true, BackpressurePolicy{})
+
+			params, _ := json.Marshal(protocol.TaskSendParams{ID: "t1"})
+			resp := s.HandleMessage(context.Background(), &JsonRpcRaw{
+				Version: protocol.JsonRpcVersion,
+				ID:      1,
+				Method:  protocol.MethodCancelTask,
+				Params:  params,
+			})
+
+			code := 0
+			if resp.Error != nil {
+				code = resp.Error.Code
+			}
+
+			if code != tt.wantCode {
+				t.Fatalf("error code = %d, want %d", code, tt.wantCode)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			_, final := sub.q.next(ctx)
+			if final != tt.wantFinal {
+				t.Fatalf("final event sent = %v, want %v", final, tt.wantFinal)
+			}
+		})
+	}
+}
+
+func TestCancelRejectsArtifacts(t *testing.T) {
+	working := make(chan struct{})
+	added := make(chan error, 1)
+
+	m := newTestManager(func(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+		close(working)
+		<-ctx.Done()
+
+		added <- updater.AddArtifact(context.WithoutCancel(ctx), protocol.Artifact{
+			Parts: []protocol.Part{protocol.NewTextPart("late")},
+		})
+		return ctx.Err()
+	})
+
+	ctx := context.Background()
+	if _, err := m.send(ctx, sendParams("t1", "hello"), false, 0); err != nil {
+		t.Fatalf("send = %v", err)
+	}
+
+	<-working
+	if _, err := m.CancelTask(ctx, sendParams("t1", "")); err != nil {
+		t.Fatalf("CancelTask = %v", err)
+	}
+
+	if err := <-added; err != ErrTaskFinished {
+		t.Fatalf("AddArtifact after cancel = %v, want ErrTaskFinished", err)
+	}
+
+	waitDone(t, m, "t1")
+	task, err := m.GetTask(ctx, sendParams("t1", ""))
+	if err != nil || task.Status.State != protocol.TaskStateCanceled || len(task.Artifacts) != 0 {
+		t.Fatalf("task = %+v, %v, want canceled without artifacts", task, err)
+	}
+}
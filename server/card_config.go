@@ -0,0 +1,331 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+type (
+	// CardConfig is the agent card and host settings of a configuration file, e.g.
+	//
+	//	{
+	//	  "card": {"name": "Recipe Agent", "url": "https://recipes.example.com", "version": "1.2.0", ...},
+	//	  "host": {"addr": ":8080", "keepAlive": "15s", "retryHint": "3s", "cardCacheControl": "public, max-age=60"}
+	//	}
+	//
+	// See [LoadCardConfig] for the environment variables overriding it.
+	CardConfig struct {
+		Card protocol.AgentCard `json:"card"`
+		Host HostConfig         `json:"host"`
+	}
+
+	// HostConfig is the part of a [CardConfig] configuring the [StandardA2AServerHost].
+	HostConfig struct {
+		Addr             string   `json:"addr,omitempty"`
+		KeepAlive        Duration `json:"keepAlive,omitempty"`
+		RetryHint        Duration `json:"retryHint,omitempty"`
+		CardCacheControl *string  `json:"cardCacheControl,omitempty"`
+	}
+
+	// Duration is a time.Duration encoded as a string in JSON, e.g. "1m30s".
+	Duration time.Duration
+
+	// ConfigDecoder converts a configuration file to JSON, which is then decoded as a '.json' file.
+	// Only JSON is built in, this package has no dependencies: other formats such as YAML need
+	// a decoder from the caller. The JSON names and the encoding of [Duration] apply whatever
+	// the format, e.g. for YAML:
+	//
+	//	func(data []byte) ([]byte, error) {
+	//		var v any
+	//		if err := yaml.Unmarshal(data, &v); err != nil {
+	//			return nil, err
+	//		}
+	//
+	//		return json.Marshal(v)
+	//	}
+	//
+	// with a yaml package decoding mappings as map[string]any.
+	ConfigDecoder func(data []byte) ([]byte, error)
+
+	// ConfigOption configures [LoadCardConfig] and [WatchCardConfig].
+	ConfigOption func(*configLoader)
+
+	configLoader struct {
+		envPrefix string
+		decoders  map[string]ConfigDecoder
+		interval  time.Duration
+		logger    *slog.Logger
+		onReload  func(config *CardConfig)
+	}
+)
+
+// WithEnvPrefix sets the prefix of the environment variables overriding the configuration.
+// Default is 'A2A_'.
+func WithEnvPrefix(prefix string) ConfigOption {
+	return func(l *configLoader) {
+		l.envPrefix = prefix
+	}
+}
+
+// WithConfigDecoder sets the decoder of the configuration files with the extension, e.g. '.yaml',
+// see [ConfigDecoder]. Only '.json' is decoded by default, a '.yaml' file is rejected without one.
+func WithConfigDecoder(ext string, decoder ConfigDecoder) ConfigOption {
+	return func(l *configLoader) {
+		l.decoders[strings.ToLower(ext)] = decoder
+	}
+}
+
+// WithConfigPollInterval sets how often [WatchCardConfig] checks the file for changes. Default is 2 seconds.
+func WithConfigPollInterval(interval time.Duration) ConfigOption {
+	return func(l *configLoader) {
+		l.interval = interval
+	}
+}
+
+// WithConfigLogger sets the logger of the reloads by [WatchCardConfig]. Default is [slog.Default].
+func WithConfigLogger(logger *slog.Logger) ConfigOption {
+	return func(l *configLoader) {
+		l.logger = logger
+	}
+}
+
+// WithReloadHook sets a function called by [WatchCardConfig] after each applied reload.
+func WithReloadHook(fn func(config *CardConfig)) ConfigOption {
+	return func(l *configLoader) {
+		l.onReload = fn
+	}
+}
+
+func newConfigLoader(opts []ConfigOption) *configLoader {
+	l := &configLoader{
+		envPrefix: "A2A_",
+		decoders:  map[string]ConfigDecoder{".json": func(data []byte) ([]byte, error) { return data, nil }},
+		interval:  2 * time.Second,
+		logger:    slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// LoadCardConfig reads the configuration file, decoded by its extension: JSON, or the formats
+// of the decoders set with [WithConfigDecoder].
+// Then the environment variables below override it, with the prefix set by [WithEnvPrefix]:
+//   - A2A_NAME, A2A_URL, A2A_VERSION: the name, url and version of the card.
+//   - A2A_ADDR, A2A_KEEP_ALIVE, A2A_RETRY_HINT, A2A_CARD_CACHE_CONTROL: the host settings.
+//
+// The card must be valid, see [protocol.AgentCard.Validate].
+func LoadCardConfig(path string, opts ...ConfigOption) (*CardConfig, error) {
+	config, _, err := newConfigLoader(opts).load(path)
+	return config, err
+}
+
+// WatchCardConfig loads the configuration file as [LoadCardConfig] and sets its card on the server,
+// then polls the file until ctx is done, swapping in the card of every change, see [A2AServer.SetAgentCard].
+// An invalid change is logged and rejected, the server keeps its card. The host settings are
+// applied once, by the caller, with [CardConfig.HostOptions].
+func WatchCardConfig(ctx context.Context, server *A2AServer, path string, opts ...ConfigOption) (*CardConfig, error) {
+	l := newConfigLoader(opts)
+
+	config, sum, err := l.load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := server.SetAgentCard(config.Card); err != nil {
+		return nil, err
+	}
+
+	go l.watch(ctx, server, path, sum)
+	return config, nil
+}
+
+// watch reloads the file whenever its content changes.
+func (l *configLoader) watch(ctx context.Context, server *A2AServer, path string, sum [sha256.Size]byte) {
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			l.logger.Warn("read agent card config error", "path", path, "error", err)
+			continue
+		}
+
+		if sha256.Sum256(data) == sum {
+			continue
+		}
+
+		// a rejected change is not retried until the file changes again.
+		sum = sha256.Sum256(data)
+
+		config, err := l.decode(path, data)
+		if err == nil {
+			err = server.SetAgentCard(config.Card)
+		}
+
+		if err != nil {
+			l.logger.Error("agent card config rejected", "path", path, "error", err)
+			continue
+		}
+
+		l.logger.Info("agent card config reloaded", "path", path, "version", config.Card.Version)
+		if l.onReload != nil {
+			l.onReload(config)
+		}
+	}
+}
+
+// load reads and decodes the file, returning the hash of its content.
+func (l *configLoader) load(path string) (*CardConfig, [sha256.Size]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, [sha256.Size]byte{}, fmt.Errorf("read agent card config error: %w", err)
+	}
+
+	config, err := l.decode(path, data)
+	return config, sha256.Sum256(data), err
+}
+
+// decode decodes the content of the file, overrides it from the environment and validates the card.
+func (l *configLoader) decode(path string, data []byte) (*CardConfig, error) {
+	decode, ok := l.decoders[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil, fmt.Errorf("no decoder for agent card config [%s]", path)
+	}
+
+	data, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode agent card config error: %w", err)
+	}
+
+	config := new(CardConfig)
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("decode agent card config error: %w", err)
+	}
+
+	if err := l.override(config); err != nil {
+		return nil, err
+	}
+
+	if err := config.Card.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid agent card: %w", err)
+	}
+
+	return config, nil
+}
+
+// override sets the fields of the config having an environment variable.
+func (l *configLoader) override(config *CardConfig) error {
+	env := func(name string, set func(v string) error) error {
+		v, ok := os.LookupEnv(l.envPrefix + name)
+		if !ok {
+			return nil
+		}
+
+		if err := set(v); err != nil {
+			return fmt.Errorf("%s%s: %w", l.envPrefix, name, err)
+		}
+
+		return nil
+	}
+
+	str := func(dst *string) func(string) error {
+		return func(v string) error {
+			*dst = v
+			return nil
+		}
+	}
+
+	duration := func(dst *Duration) func(string) error {
+		return func(v string) error {
+			d, err := time.ParseDuration(v)
+			*dst = Duration(d)
+			return err
+		}
+	}
+
+	cacheControl := func(v string) error {
+		config.Host.CardCacheControl = &v
+		return nil
+	}
+
+	for _, err := range []error{
+		env("NAME", str(&config.Card.Name)),
+		env("URL", str(&config.Card.Url)),
+		env("VERSION", str(&config.Card.Version)),
+		env("ADDR", str(&config.Host.Addr)),
+		env("KEEP_ALIVE", duration(&config.Host.KeepAlive)),
+		env("RETRY_HINT", duration(&config.Host.RetryHint)),
+		env("CARD_CACHE_CONTROL", cacheControl),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HostOptions returns the options of the host settings, e.g. NewA2AHost(c.Host.Addr, c.HostOptions()...).
+func (c *CardConfig) HostOptions() []HostOption {
+	var opts []HostOption
+	if c.Host.KeepAlive > 0 {
+		opts = append(opts, WithKeepAlive(time.Duration(c.Host.KeepAlive)))
+	}
+
+	if c.Host.RetryHint > 0 {
+		opts = append(opts, WithRetryHint(time.Duration(c.Host.RetryHint)))
+	}
+
+	if c.Host.CardCacheControl != nil {
+		opts = append(opts, WithCardCacheControl(*c.Host.CardCacheControl))
+	}
+
+	return opts
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting a string such as "1m30s" or a number of nanoseconds.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n int64
+		if json.Unmarshal(data, &n) != nil {
+			return fmt.Errorf("invalid duration %s", data)
+		}
+
+		*d = Duration(n)
+		return nil
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+	return nil
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+const testCardConfig = `{
+  "card": {"name": "test", "url": "https://agent.example.com", "version": "1.0.0", "capabilities": {}, "skills": [{"id": "echo"}]},
+  "host": {"addr": ":8080", "keepAlive": "15s"}
+}`
+
+func TestLoadCardConfig(t *testing.T) {
+	// base64 stands for a format converted to JSON, such as YAML.
+	b64 := WithConfigDecoder(".b64", func(data []byte) ([]byte, error) {
+		return base64.StdEncoding.DecodeString(string(data))
+	})
+
+	tests := []struct {
+		name        string
+		file        string
+		content     string
+		env         map[string]string
+		wantErr     bool
+		wantVersion string
+	}{
+		{name: "json", file: "card.json", content: testCardConfig, wantVersion: "1.0.0"},
+		{name: "converted to json", file: "card.b64", content: base64.StdEncoding.EncodeToString([]byte(testCardConfig)), wantVersion: "1.0.0"},
+		{name: "environment override", file: "card.json", content: testCardConfig, env: map[string]string{"TEST_VERSION": "2.0.0"}, wantVersion: "2.0.0"},
+		{name: "no decoder", file: "card.yaml", content: testCardConfig, wantErr: true},
+		{name: "invalid card", file: "card.json", content: `{"card": {"name": "test"}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := LoadCardConfig(path, b64, WithEnvPrefix("TEST_"))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("LoadCardConfig = %+v, want an error", config)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("LoadCardConfig = %v", err)
+			}
+
+			if config.Card.Version != tt.wantVersion || time.Duration(config.Host.KeepAlive) != 15*time.Second {
+				t.Fatalf("config = %+v, want version %s and keepAlive 15s", config, tt.wantVersion)
+			}
+		})
+	}
+}
+
+// lockedBuffer is a bytes.Buffer safe for the watcher to log to while the test reads it.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWatchCardConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "card.json")
+
+	// write replaces the file at once, the watcher never reads it half written.
+	write := func(content string) {
+		t.Helper()
+
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Rename(tmp, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(testCardConfig)
+
+	var logs lockedBuffer
+	reloaded := make(chan string, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := NewA2AServerWithExecutor(protocol.AgentCard{Name: "test", Url: "http://localhost", Version: "0.1.0"}, AgentExecutorFunc(
+		func(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+			return updater.Complete(ctx)
+		}))
+
+	_, err := WatchCardConfig(ctx, s, path,
+		WithConfigPollInterval(5*time.Millisecond),
+		WithConfigLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+		WithReloadHook(func(config *CardConfig) { reloaded <- config.Card.Version }))
+	if err != nil {
+		t.Fatalf("WatchCardConfig = %v", err)
+	}
+
+	if got := s.AgentCard().Version; got != "1.0.0" {
+		t.Fatalf("version = %s, want the one of the file", got)
+	}
+
+	// a valid change is swapped in.
+	write(strings.Replace(testCardConfig, "1.0.0", "2.0.0", 1))
+	select {
+	case v := <-reloaded:
+		if v != "2.0.0" {
+			t.Fatalf("reloaded version %s, want 2.0.0", v)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("change not reloaded")
+	}
+
+	if got := s.AgentCard().Version; got != "2.0.0" {
+		t.Fatalf("version = %s after reload, want 2.0.0", got)
+	}
+
+	// an invalid change is rejected, the server keeps its card.
+	write(`{"card": {"name": "test"}}`)
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(logs.String(), "agent card config rejected") {
+		if time.Now().After(deadline) {
+			t.Fatal("invalid change not rejected")
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if got := s.AgentCard().Version; got != "2.0.0" {
+		t.Fatalf("version = %s after an invalid change, want 2.0.0", got)
+	}
+
+	select {
+	case v := <-reloaded:
+		t.Fatalf("invalid change reloaded as %s", v)
+	default:
+	}
+}
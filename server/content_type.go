@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+// checkContentTypes rejects a message with parts the agent doesn't accept, or asking for
+// output modes the agent doesn't produce, with [protocol.ErrIncompatibleContentTypes].
+// The modes are the ones of the skill requested by [protocol.MetadataSkillID] if it declares them,
+// the defaults of the agent card of the caller otherwise.
+// A skill requested that is not on the card of the caller is rejected with [protocol.ErrSkillNotFound].
+func (s *A2AServer) checkContentTypes(ctx context.Context, params *protocol.TaskSendParams) error {
+	card := s.AgentCardFor(ctx)
+
+	skillID := taskSkill(params.Metadata)
+	if _, ok := card.Skill(skillID); skillID != "" && !ok {
+		return protocol.ErrSkillNotFound.New().Args(skillID)
+	}
+
+	input, output := taskModes(&card, skillID)
+
+	incompatible := func(reason string) error {
+		return protocol.ErrIncompatibleContentTypes.New().
+			Args(reason).
+			Data(protocol.ContentTypesData{InputModes: input, OutputModes: output})
+	}
+
+	for _, part := range params.Message.Parts {
+		if !protocol.AcceptsPart(input, part) {
+			return incompatible(fmt.Sprintf("input [%s] is not accepted", part.MimeType()))
+		}
+	}
+
+	if _, ok := protocol.SelectOutputMode(params.AcceptedOutputModes, output); !ok {
+		return incompatible(fmt.Sprintf("none of the output modes %v is produced", params.AcceptedOutputModes))
+	}
+
+	return nil
+}
+
+// taskModes returns the input and output modes of the skill, falling back to the defaults of the card.
+func taskModes(card *protocol.AgentCard, skillID string) (input, output []string) {
+	input, output = card.DefaultInputModes, card.DefaultOutputModes
+
+	if skill, ok := card.Skill(skillID); ok && skillID != "" {
+		if len(skill.InputModes) > 0 {
+			input = skill.InputModes
+		}
+
+		if len(skill.OutputModes) > 0 {
+			output = skill.OutputModes
+		}
+	}
+
+	return input, output
+}
+
+// acceptsParts reports whether the modes accept every part.
+func acceptsParts(modes []string, parts []protocol.Part) bool {
+	for _, part := range parts {
+		if !protocol.AcceptsPart(modes, part) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+type (
+	// sendDedup detects the tasks/send retried by clients, see [WithSendDedup].
+	// Only the last send of each task is remembered, so a follow-up message is never
+	// taken for a retry of an earlier one.
+	sendDedup struct {
+		retention time.Duration
+
+		mu        sync.Mutex
+		lastSweep time.Time
+		sends     map[string]*dedupSend
+	}
+
+	// dedupSend is the last tasks/send of a task.
+	dedupSend struct {
+		key string
+
+		// closed once the send returned, task and err are set before.
+		done chan struct{}
+		task *protocol.Task
+		err  error
+
+		// guarded by sendDedup.mu, zero until the send returned.
+		expires time.Time
+	}
+)
+
+func newSendDedup(retention time.Duration) *sendDedup {
+	return &sendDedup{
+		retention: retention,
+		lastSweep: time.Now(),
+		sends:     make(map[string]*dedupSend),
+	}
+}
+
+// begin returns the last send of the task and true if it has the same key.
+// Otherwise the send is recorded as the last send of the task, to be ended by [sendDedup.end].
+func (d *sendDedup) begin(taskID, key string) (*dedupSend, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.sweep()
+
+	prev, ok := d.sends[taskID]
+	if ok && prev.key == key && (prev.expires.IsZero() || time.Now().Before(prev.expires)) {
+		return prev, true
+	}
+
+	e := &dedupSend{key: key, done: make(chan struct{})}
+	d.sends[taskID] = e
+	return e, false
+}
+
+// end records the result of the send. A failed send is forgotten, so it can be retried.
+func (d *sendDedup) end(taskID string, e *dedupSend, task *protocol.Task, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	e.task, e.err = task, err
+	close(e.done)
+
+	if d.sends[taskID] != e {
+		return
+	}
+
+	if err != nil {
+		delete(d.sends, taskID)
+		return
+	}
+
+	e.expires = time.Now().Add(d.retention)
+}
+
+// forget forgets the last send of the task, e.g. once the task is evicted.
+func (d *sendDedup) forget(taskID string) {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.sends, taskID)
+}
+
+// sweep forgets the sends past their retention, must be called with d.mu held.
+func (d *sendDedup) sweep() {
+	if time.Since(d.lastSweep) < d.retention/2 {
+		return
+	}
+
+	now := time.Now()
+	d.lastSweep = now
+	for id, e := range d.sends {
+		if !e.expires.IsZero() && now.After(e.expires) {
+			delete(d.sends, id)
+		}
+	}
+}
+
+// dedupKey returns the [protocol.MetadataIdempotencyKey] of the send if any, otherwise the hash
+// of its message if messages is set, or empty if the send is not to be deduplicated.
+func dedupKey(params *protocol.TaskSendParams, messages bool) string {
+	if key, ok := params.Metadata[protocol.MetadataIdempotencyKey].(string); ok && key != "" {
+		return "key:" + key
+	}
+
+	if !messages {
+		return ""
+	}
+
+	// map keys are sorted, the encoding of equal messages is the same.
+	raw, _ := json.Marshal(params.Message)
+	sum := sha256.Sum256(raw)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
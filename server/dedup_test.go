@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+func TestDedupKey(t *testing.T) {
+	withKey := sendParams("t1", "yes")
+	withKey.Metadata = map[string]any{protocol.MetadataIdempotencyKey: "k1"}
+
+	tests := []struct {
+		name     string
+		params   *protocol.TaskSendParams
+		messages bool
+		want     string
+	}{
+		{name: "idempotency key", params: withKey, want: "key:k1"},
+		{name: "idempotency key over message", params: withKey, messages: true, want: "key:k1"},
+		{name: "no key", params: sendParams("t1", "yes"), want: ""},
+		{name: "message hash opt-in", params: sendParams("t1", "yes"), messages: true, want: "sha256:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dedupKey(tt.params, tt.messages)
+			if (tt.want == "" && got != "") || !strings.HasPrefix(got, tt.want) {
+				t.Fatalf("dedupKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedupRepeatedMessages(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		key   bool
+		turns int
+	}{
+		{name: "repeated follow-up without key is a new turn", turns: 2},
+		{name: "retry with the same key", key: true, turns: 1},
+		{name: "repeated message with message dedup", opts: []Option{WithMessageDedup()}, turns: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var turns atomic.Int32
+			s := NewA2AServerWithExecutor(protocol.AgentCard{Name: "test"}, AgentExecutorFunc(
+				func(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+					turns.Add(1)
+					return updater.RequireInput(ctx)
+				}), tt.opts...)
+
+			for i := 0; i < 2; i++ {
+				params := sendParams("t1", "yes")
+				if tt.key {
+					params.Metadata = map[string]any{protocol.MetadataIdempotencyKey: "k1"}
+				}
+
+				if _, err := s.sendTask(context.Background(), params); err != nil {
+					t.Fatalf("sendTask = %v", err)
+				}
+			}
+
+			if got := int(turns.Load()); got != tt.turns {
+				t.Fatalf("executed %d turns, want %d", got, tt.turns)
+			}
+		})
+	}
+}
+
+func TestDedupConcurrentSends(t *testing.T) {
+	var runs atomic.Int32
+	s := NewA2AServerWithExecutor(protocol.AgentCard{Name: "test"}, AgentExecutorFunc(
+		func(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+			runs.Add(1)
+			time.Sleep(50 * time.Millisecond)
+			return updater.Complete(ctx)
+		}))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			params := sendParams("t1", "hello")
+			params.Metadata = map[string]any{protocol.MetadataIdempotencyKey: "k1"}
+			if _, err := s.sendTask(context.Background(), params); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("sendTask = %v", err)
+	}
+
+	if got := runs.Load(); got != 1 {
+		t.Fatalf("executed %d times, want 1", got)
+	}
+}
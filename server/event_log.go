@@ -0,0 +1,302 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+type (
+	// eventLog keeps a bounded log of the stream events of each task,
+	// so a client resubscribing with the id of the last event it received
+	// can replay what it missed before switching to live events.
+	//
+	// Event ids have the form '<epoch>-<seq>', epoch identifies the log of the task,
+	// so an id from an evicted (and recreated) log replays everything retained.
+	eventLog struct {
+		mu        sync.Mutex
+		capacity  int
+		retention time.Duration
+		lastSweep time.Time
+		tasks     map[string]*taskEvents
+	}
+
+	taskEvents struct {
+		epoch     int64
+		seq       uint64
+		events    []loggedEvent
+		producers int
+		final     bool
+		updated   time.Time
+		subs      map[*subscription]struct{}
+
+		// serializes delivery, so every subscriber sees events in log order.
+		// The event is pushed to the subscribers concurrently, a stream blocked by its policy
+		// doesn't hold back the delivery of the event to the other streams.
+		deliverMu sync.Mutex
+	}
+
+	loggedEvent struct {
+		id    string
+		seq   uint64
+		event any
+	}
+
+	subscription struct {
+		// closed by the log when the task stream ends.
+		q *streamQueue
+
+		// closed by the subscriber when it leaves.
+		done chan struct{}
+		once sync.Once
+	}
+)
+
+func newEventLog(capacity int, retention time.Duration) *eventLog {
+	return &eventLog{
+		capacity:  capacity,
+		retention: retention,
+		lastSweep: time.Now(),
+		tasks:     make(map[string]*taskEvents),
+	}
+}
+
+// subscribe returns the events after lastEventID and a subscription for live events.
+// The subscription is nil if the task stream has already ended with a final event,
+// unless reopen is set, i.e. a new stream of the task is starting (e.g. the next turn of a multi-turn task).
+// producing reports whether live events are being produced for the task.
+func (l *eventLog) subscribe(taskID, lastEventID string, reopen bool, policy BackpressurePolicy) (replay []loggedEvent, sub *subscription, producing bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep()
+	t := l.task(taskID)
+	if reopen {
+		t.final = false
+	}
+
+	if lastEventID != "" {
+		epoch, seq, err := parseEventID(lastEventID)
+		if err != nil || epoch != t.epoch {
+			seq = 0
+		}
+
+		for _, e := range t.events {
+			if e.seq > seq {
+				replay = append(replay, e)
+			}
+		}
+	}
+
+	if t.final {
+		return replay, nil, false
+	}
+
+	sub = &subscription{
+		q:    newStreamQueue(policy),
+		done: make(chan struct{}),
+	}
+
+	t.subs[sub] = struct{}{}
+	return replay, sub, t.producers > 0
+}
+
+// unsubscribe removes the subscription, it's safe to call more than once.
+func (l *eventLog) unsubscribe(taskID string, sub *subscription) {
+	sub.once.Do(func() {
+		close(sub.done)
+	})
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if t, ok := l.tasks[taskID]; ok {
+		delete(t.subs, sub)
+	}
+}
+
+// produce registers a producer of live events for the task.
+// The returned function unregisters it, once the last producer is gone the subscriptions are closed.
+func (l *eventLog) produce(taskID string) func() {
+	l.mu.Lock()
+	t := l.task(taskID)
+	t.producers++
+	l.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.deliverMu.Lock()
+			defer t.deliverMu.Unlock()
+
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			t.producers--
+			t.updated = time.Now()
+			if t.producers == 0 {
+				closeSubs(t)
+			}
+		})
+	}
+}
+
+// append logs the event and delivers it to the subscribers of the task.
+// A final event of a stream already ended is dropped, e.g. the canceled status emitted by both
+// the server and the handler.
+func (l *eventLog) append(taskID string, event any) {
+	l.mu.Lock()
+	t := l.task(taskID)
+	l.mu.Unlock()
+
+	// lock order is always deliverMu before mu.
+	// holding deliverMu while appending makes delivery follow log order.
+	t.deliverMu.Lock()
+	defer t.deliverMu.Unlock()
+
+	l.mu.Lock()
+	final := isFinal(event)
+	if final && t.final {
+		l.mu.Unlock()
+		return
+	}
+
+	t.seq++
+	e := loggedEvent{
+		id:    formatEventID(t.epoch, t.seq),
+		seq:   t.seq,
+		event: event,
+	}
+
+	t.events = append(t.events, e)
+	if l.capacity > 0 && len(t.events) > l.capacity {
+		t.events = t.events[len(t.events)-l.capacity:]
+	}
+
+	t.updated = time.Now()
+	if final {
+		t.final = true
+	}
+
+	subs := make([]*subscription, 0, len(t.subs))
+	for sub := range t.subs {
+		subs = append(subs, sub)
+	}
+	l.mu.Unlock()
+
+	push(subs, e)
+
+	if final {
+		l.mu.Lock()
+		closeSubs(t)
+		l.mu.Unlock()
+	}
+}
+
+// push delivers the event to every subscription, each one independently of the others.
+// It returns once every subscription has taken, dropped or been disconnected from the event.
+func push(subs []*subscription, e loggedEvent) {
+	if len(subs) == 1 {
+		subs[0].q.push(e, subs[0].done)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, sub := range subs {
+		wg.Add(1)
+		go func(sub *subscription) {
+			defer wg.Done()
+			sub.q.push(e, sub.done)
+		}(sub)
+	}
+
+	wg.Wait()
+}
+
+// remove evicts the log of the task, unless a stream of the task is still open.
+func (l *eventLog) remove(taskID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if t, ok := l.tasks[taskID]; ok && t.producers == 0 && len(t.subs) == 0 {
+		delete(l.tasks, taskID)
+	}
+}
+
+// closeSubs closes the subscriptions of the task, must be called with both locks held.
+func closeSubs(t *taskEvents) {
+	for sub := range t.subs {
+		sub.q.close()
+		delete(t.subs, sub)
+	}
+}
+
+// task returns the log of the task, must be called with l.mu held.
+func (l *eventLog) task(taskID string) *taskEvents {
+	t, ok := l.tasks[taskID]
+	if !ok {
+		t = &taskEvents{
+			epoch:   time.Now().UnixNano(),
+			updated: time.Now(),
+			subs:    make(map[*subscription]struct{}),
+		}
+
+		l.tasks[taskID] = t
+	}
+
+	return t
+}
+
+// sweep evicts logs idle for longer than retention, must be called with l.mu held.
+func (l *eventLog) sweep() {
+	if l.retention <= 0 || time.Since(l.lastSweep) < l.retention/2 {
+		return
+	}
+
+	now := time.Now()
+	l.lastSweep = now
+	for id, t := range l.tasks {
+		if t.producers == 0 && len(t.subs) == 0 && now.Sub(t.updated) > l.retention {
+			delete(l.tasks, id)
+		}
+	}
+}
+
+func formatEventID(epoch int64, seq uint64) string {
+	return fmt.Sprintf("%d-%d", epoch, seq)
+}
+
+func parseEventID(id string) (int64, uint64, error) {
+	epoch, seq, ok := strings.Cut(id, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("malformed event id [%s]", id)
+	}
+
+	e, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed event id [%s]", id)
+	}
+
+	s, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed event id [%s]", id)
+	}
+
+	return e, s, nil
+}
+
+// isFinal reports whether the event ends the task stream.
+func isFinal(event any) bool {
+	switch e := event.(type) {
+	case *protocol.TaskStatusUpdateEvent:
+		return e != nil && e.Final
+	case protocol.TaskStatusUpdateEvent:
+		return e.Final
+	}
+
+	return false
+}
@@ -0,0 +1,385 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+func statusEvent(taskID string, final bool) *protocol.TaskStatusUpdateEvent {
+	return &protocol.TaskStatusUpdateEvent{
+		ID:     taskID,
+		Status: protocol.TaskStatus{State: protocol.TaskStateWorking},
+		Final:  final,
+	}
+}
+
+// checkNoLeak fails the test if goroutines started after before are still running shortly after.
+func checkNoLeak(t *testing.T, before int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			n := runtime.Stack(buf, true)
+			t.Fatalf("leaked goroutines: %d, want %d\n%s", runtime.NumGoroutine(), before, buf[:n])
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestEventLogReplay(t *testing.T) {
+	l := newEventLog(3, time.Minute)
+	done := l.produce("t1")
+	for i := 0; i < 5; i++ {
+		l.append("t1", statusEvent("t1", false))
+	}
+	done()
+
+	epoch := l.tasks["t1"].epoch
+	tests := []struct {
+		name        string
+		lastEventID string
+		want        []uint64
+	}{
+		{"no last event id", "", nil},
+		{"replay after id", formatEventID(epoch, 3), []uint64{4, 5}},
+		{"up to date", formatEventID(epoch, 5), nil},
+		{"trimmed id replays what is retained", formatEventID(epoch, 1), []uint64{3, 4, 5}},
+		{"other epoch replays what is retained", formatEventID(epoch-1, 4), []uint64{3, 4, 5}},
+		{"malformed id replays what is retained", "oops", []uint64{3, 4, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replay, sub, producing := l.subscribe("t1", tt.lastEventID, false, BackpressurePolicy{})
+			defer l.unsubscribe("t1", sub)
+
+			var got []uint64
+			for _, e := range replay {
+				got = append(got, e.seq)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("replayed %v, want %v", got, tt.want)
+			}
+
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("replayed %v, want %v", got, tt.want)
+				}
+			}
+
+			if sub == nil || producing {
+				t.Errorf("subscription %v, producing %v, want a subscription and no producer", sub, producing)
+			}
+		})
+	}
+}
+
+func TestEventLogFinal(t *testing.T) {
+	l := newEventLog(10, time.Minute)
+	_, sub, _ := l.subscribe("t1", "", true, BackpressurePolicy{})
+
+	l.append("t1", statusEvent("t1", true))
+
+	// the duplicate final event is dropped.
+	l.append("t1", statusEvent("t1", true))
+
+	if _, ok := sub.q.next(context.Background()); !ok {
+		t.Fatal("final event not delivered")
+	}
+
+	if _, ok := sub.q.next(context.Background()); ok {
+		t.Fatal("stream not ended by the final event")
+	}
+
+	replay, sub, _ := l.subscribe("t1", formatEventID(l.tasks["t1"].epoch, 0), false, BackpressurePolicy{})
+	if len(replay) != 1 || sub != nil {
+		t.Fatalf("resubscribe after final: replayed %d, subscription %v, want 1 and none", len(replay), sub)
+	}
+
+	// the next turn of the task reopens the stream.
+	if _, sub, _ := l.subscribe("t1", "", true, BackpressurePolicy{}); sub == nil {
+		t.Fatal("reopened stream has no subscription")
+	}
+}
+
+func TestEventLogSlowSubscriber(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	l := newEventLog(10, time.Minute)
+	policy := BackpressurePolicy{Mode: BackpressureBlock, BufferSize: 1}
+	_, slow, _ := l.subscribe("t1", "", true, policy)
+	_, fast, _ := l.subscribe("t1", "", true, policy)
+
+	done := l.produce("t1")
+	l.append("t1", statusEvent("t1", false))
+
+	appended := make(chan struct{})
+	go func() {
+		defer close(appended)
+		l.append("t1", statusEvent("t1", false))
+	}()
+
+	// the fast client gets both events while the slow one blocks the producer.
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		_, ok := fast.q.next(ctx)
+		cancel()
+
+		if !ok {
+			t.Fatalf("fast subscriber did not get event %d", i+1)
+		}
+	}
+
+	select {
+	case <-appended:
+		t.Fatal("producer not blocked by the slow subscriber")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// the slow client leaving releases the producer.
+	l.unsubscribe("t1", slow)
+	<-appended
+
+	done()
+	l.unsubscribe("t1", fast)
+	checkNoLeak(t, before)
+}
+
+func TestEventLogNoLeak(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy BackpressurePolicy
+		leave  bool
+	}{
+		{"block, client leaves", BackpressurePolicy{Mode: BackpressureBlock, BufferSize: 1}, true},
+		{"block timeout", BackpressurePolicy{Mode: BackpressureBlock, BufferSize: 1, BlockTimeout: 20 * time.Millisecond}, false},
+		{"drop status", BackpressurePolicy{Mode: BackpressureDropStatus, BufferSize: 1}, false},
+		{"disconnect", BackpressurePolicy{Mode: BackpressureDisconnect, BufferSize: 1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := runtime.NumGoroutine()
+
+			l := newEventLog(10, time.Minute)
+			subs := make([]*subscription, 3)
+			for i := range subs {
+				_, subs[i], _ = l.subscribe("t1", "", true, tt.policy)
+			}
+
+			produced := make(chan struct{})
+			go func() {
+				defer close(produced)
+
+				done := l.produce("t1")
+				defer done()
+
+				for i := 0; i < 5; i++ {
+					l.append("t1", statusEvent("t1", i == 4))
+				}
+			}()
+
+			if tt.leave {
+				time.Sleep(20 * time.Millisecond)
+				for _, sub := range subs {
+					l.unsubscribe("t1", sub)
+				}
+			}
+
+			select {
+			case <-produced:
+			case <-time.After(2 * time.Second):
+				t.Fatal("producer blocked by clients not reading")
+			}
+
+			for _, sub := range subs {
+				l.unsubscribe("t1", sub)
+			}
+
+			checkNoLeak(t, before)
+		})
+	}
+}
+
+func TestParseEventID(t *testing.T) {
+	tests := []struct {
+		id    string
+		epoch int64
+		seq   uint64
+		err   string
+	}{
+		{id: "12-34", epoch: 12, seq: 34},
+		{id: "1234", err: "malformed"},
+		{id: "a-1", err: "malformed"},
+		{id: "1-b", err: "malformed"},
+	}
+
+	for _, tt := range tests {
+		epoch, seq, err := parseEventID(tt.id)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("parseEventID(%s) error = %v, want %s", tt.id, err, tt.err)
+			}
+
+			continue
+		}
+
+		if err != nil || epoch != tt.epoch || seq != tt.seq {
+			t.Errorf("parseEventID(%s) = %d, %d, %v", tt.id, epoch, seq, err)
+		}
+	}
+}
+
+// streamHandler streams the events sent to events as the task of tasks/sendSubscribe.
+type streamHandler struct {
+	protocol.IA2AProtocol
+	events chan any
+}
+
+func (h streamHandler) AgentCard() protocol.AgentCard {
+	card := protocol.AgentCard{Name: "test"}
+	card.Capabilities.Streaming = ptr(true)
+	return card
+}
+
+func (h streamHandler) GetTask(ctx context.Context, params *protocol.TaskSendParams) (*protocol.Task, error) {
+	return nil, protocol.ErrTaskNotFound.New().Args(params.ID)
+}
+
+func (h streamHandler) SubscribeTask(ctx context.Context, params *protocol.TaskSendParams) (chan any, error) {
+	return h.events, nil
+}
+
+// sseEvent is an event of a task stream, as received by the client.
+type sseEvent struct {
+	id   string
+	text string
+}
+
+// openStream posts the streaming request, and sends the events of the stream to the channel
+// returned, closed when the stream ends.
+func openStream(t *testing.T, url, method, lastEventID string) <-chan sseEvent {
+	t.Helper()
+
+	body := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":%q,"params":{"id":"t1","message":{"role":"user","parts":[]}}}`, method)
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s = %v", method, err)
+	}
+
+	ch := make(chan sseEvent)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+
+		var e sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				e.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				var data struct {
+					Result protocol.TaskStatusUpdateEvent `json:"result"`
+				}
+
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &data)
+				if msg := data.Result.Status.Message; msg != nil && len(msg.Parts) > 0 {
+					e.text = msg.Parts[0].Text
+				}
+			case line == "" && e.id != "":
+				ch <- e
+				e = sseEvent{}
+			}
+		}
+	}()
+
+	return ch
+}
+
+// nextEvent returns the next event of the stream.
+func nextEvent(t *testing.T, ch <-chan sseEvent) sseEvent {
+	t.Helper()
+
+	select {
+	case e, ok := <-ch:
+		if !ok {
+			t.Fatal("stream ended")
+		}
+
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event")
+	}
+
+	return sseEvent{}
+}
+
+// TestResubscribeLastEventID resumes a stream over HTTP from the Last-Event-ID header.
+func TestResubscribeLastEventID(t *testing.T) {
+	h := streamHandler{events: make(chan any)}
+	s := NewA2AServer(h)
+
+	_, rpc := NewA2AHost(":0").handlers(s)
+	srv := httptest.NewServer(rpc)
+	defer srv.Close()
+
+	status := func(text string, final bool) *protocol.TaskStatusUpdateEvent {
+		e := statusEvent("t1", final)
+		e.Status.Message = &protocol.Message{Role: protocol.RoleAgent, Parts: []protocol.Part{protocol.NewTextPart(text)}}
+		return e
+	}
+
+	first := openStream(t, srv.URL, string(protocol.MethodSubscribeTask), "")
+
+	var received []sseEvent
+	for _, text := range []string{"a", "b", "c"} {
+		h.events <- status(text, false)
+		received = append(received, nextEvent(t, first))
+	}
+
+	// the client lost the stream after 'a': 'b' and 'c' are replayed, with their ids, then live events.
+	second := openStream(t, srv.URL, string(protocol.MethodResubscribeTask), received[0].id)
+	for _, want := range received[1:] {
+		if got := nextEvent(t, second); got != want {
+			t.Fatalf("replayed %+v, want %+v", got, want)
+		}
+	}
+
+	h.events <- status("done", true)
+	close(h.events)
+
+	for _, ch := range []<-chan sseEvent{first, second} {
+		if got := nextEvent(t, ch); got.text != "done" {
+			t.Fatalf("live event %+v, want done", got)
+		}
+
+		if _, ok := <-ch; ok {
+			t.Fatal("stream not ended after the final event")
+		}
+	}
+}
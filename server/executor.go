@@ -0,0 +1,230 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+var (
+	// ErrTaskFinished is returned by [TaskUpdater] once the task reached a final state.
+	ErrTaskFinished = errors.New("task finished, no more updates allowed")
+
+	// ErrNotWaitingInput is returned by [TaskUpdater.WaitForInput] if the task isn't in input-required.
+	ErrNotWaitingInput = errors.New("task is not waiting for input")
+
+	// ErrInputTimeout is returned by [TaskUpdater.WaitForInput] if no message arrived in time, see [WithInputTimeout].
+	ErrInputTimeout = errors.New("timed out waiting for input")
+)
+
+// AgentExecutor is the higher-level alternative to implementing [protocol.IA2AProtocol].
+// The server keeps the tasks, streams their updates and sends their push notifications,
+// the executor only does the work.
+//
+// Host it with [NewA2AServerWithExecutor].
+type AgentExecutor interface {
+	// Execute does the work of the task, whose history ends with the message just received.
+	// Progress is reported through updater. If Execute returns without reaching a final state,
+	// the task is completed, or failed if an error is returned.
+	//
+	// A multi-turn executor asks for input with [TaskUpdater.RequireInput] and either blocks
+	// in [TaskUpdater.WaitForInput], or returns and gets executed again with the follow-up message.
+	//
+	// ctx is not bound to the request, the work goes on when a streaming client disconnects.
+	// It carries the session of the task, see [SessionFromContext].
+	Execute(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error
+}
+
+// AgentExecutorFunc is an adapter to use an ordinary function as [AgentExecutor].
+type AgentExecutorFunc func(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error
+
+// Execute implements AgentExecutor.
+func (f AgentExecutorFunc) Execute(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+	return f(ctx, task, updater)
+}
+
+// TaskUpdater reports the progress of a task. Every update is saved to the task store,
+// sent to the streaming subscribers of the task and pushed to its notification url.
+//
+// Final states are input-required, completed, canceled and failed,
+// after which every update returns [ErrTaskFinished], except failing a task waiting for input.
+type TaskUpdater struct {
+	m      *taskManager
+	taskID string
+	exec   *execution
+
+	mu    sync.Mutex
+	state protocol.TaskState
+}
+
+// TaskID returns the id of the task being updated.
+func (u *TaskUpdater) TaskID() string {
+	return u.taskID
+}
+
+// Working moves the task to working, with an optional status message.
+func (u *TaskUpdater) Working(ctx context.Context, parts ...protocol.Part) error {
+	return u.status(ctx, protocol.TaskStateWorking, parts)
+}
+
+// RequireInput moves the task to input-required, with the message describing the input needed.
+// The next tasks/send of the task is delivered to [TaskUpdater.WaitForInput].
+func (u *TaskUpdater) RequireInput(ctx context.Context, parts ...protocol.Part) error {
+	return u.status(ctx, protocol.TaskStateInputRequired, parts)
+}
+
+// WaitForInput blocks until the client sends the next message of the task, which is already
+// appended to the task history, then moves the task back to working.
+// It must follow [TaskUpdater.RequireInput].
+//
+// Waiting ends with an error when ctx is done, the task is canceled, or the input timeout
+// set by [WithInputTimeout] elapses, in which case the task is failed.
+//
+// The worker of the task, see [WithWorkerPool], is given back while waiting,
+// and taken again once the input arrived.
+func (u *TaskUpdater) WaitForInput(ctx context.Context) (*protocol.Message, error) {
+	u.mu.Lock()
+	state := u.state
+	u.mu.Unlock()
+
+	if state != protocol.TaskStateInputRequired {
+		return nil, ErrNotWaitingInput
+	}
+
+	u.exec.ticket.release()
+
+	var timeout <-chan time.Time
+	if u.m.inputTimeout > 0 {
+		timer := time.NewTimer(u.m.inputTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var err error
+	select {
+	case message := <-u.exec.input:
+		return u.received(ctx, message)
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-u.exec.ctx.Done():
+		err = u.exec.ctx.Err()
+	case <-timeout:
+		err = ErrInputTimeout
+	}
+
+	// stop waiting, unless the input arrived meanwhile.
+	u.m.mu.Lock()
+	u.exec.waiting = false
+	select {
+	case message := <-u.exec.input:
+		u.m.mu.Unlock()
+		return u.received(ctx, message)
+	default:
+		u.m.mu.Unlock()
+	}
+
+	if err == ErrInputTimeout {
+		u.Fail(context.WithoutCancel(ctx), protocol.NewTextPart(err.Error()))
+	}
+
+	return nil, err
+}
+
+// received waits for a worker, then moves the task back to working with the input.
+func (u *TaskUpdater) received(ctx context.Context, message protocol.Message) (*protocol.Message, error) {
+	// the ticket is released by the execution, even if never granted.
+	u.exec.ticket = u.m.pool.requeue(u.exec.priority)
+	if err := u.exec.ticket.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := u.resume(ctx); err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// Complete moves the task to completed, with an optional status message.
+func (u *TaskUpdater) Complete(ctx context.Context, parts ...protocol.Part) error {
+	return u.status(ctx, protocol.TaskStateCompleted, parts)
+}
+
+// Fail moves the task to failed, with an optional message describing the failure.
+func (u *TaskUpdater) Fail(ctx context.Context, parts ...protocol.Part) error {
+	return u.status(ctx, protocol.TaskStateFailed, parts)
+}
+
+// OutputMode picks the output mode to produce among the offered ones, e.g. the output modes of the
+// skill, as accepted by the client with the last message, see [protocol.SelectOutputMode].
+func (u *TaskUpdater) OutputMode(offered ...string) (string, bool) {
+	u.m.mu.Lock()
+	accepted := u.exec.accepted
+	u.m.mu.Unlock()
+
+	return protocol.SelectOutputMode(accepted, offered)
+}
+
+// AddArtifact adds the artifact to the task. An artifact with Append set
+// adds its parts to the artifact of the same index.
+func (u *TaskUpdater) AddArtifact(ctx context.Context, artifact protocol.Artifact) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if isFinalState(u.state) {
+		return ErrTaskFinished
+	}
+
+	return u.m.addArtifact(ctx, u.taskID, artifact)
+}
+
+func (u *TaskUpdater) status(ctx context.Context, state protocol.TaskState, parts []protocol.Part) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	// a task waiting for input can still fail, e.g. when the input doesn't come.
+	if isFinalState(u.state) && !(u.state == protocol.TaskStateInputRequired && state == protocol.TaskStateFailed) {
+		return ErrTaskFinished
+	}
+
+	var message *protocol.Message
+	if len(parts) > 0 {
+		message = &protocol.Message{Role: protocol.RoleAgent, Parts: parts}
+	}
+
+	if err := u.m.updateStatus(ctx, u.taskID, state, message); err != nil {
+		return err
+	}
+
+	u.state = state
+	return nil
+}
+
+// resume moves the task from input-required back to working once the input arrived.
+func (u *TaskUpdater) resume(ctx context.Context) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if err := u.m.updateStatus(ctx, u.taskID, protocol.TaskStateWorking, nil); err != nil {
+		return err
+	}
+
+	u.state = protocol.TaskStateWorking
+	return nil
+}
+
+// finished reports whether the executor has moved the task to a final state.
+func (u *TaskUpdater) finished() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return isFinalState(u.state)
+}
+
+// isFinalState reports whether the state ends the task stream.
+func isFinalState(state protocol.TaskState) bool {
+	return state.IsTerminal() || state == protocol.TaskStateInputRequired
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+func sendParams(taskID, text string) *protocol.TaskSendParams {
+	return &protocol.TaskSendParams{
+		ID:      taskID,
+		Message: protocol.Message{Role: protocol.RoleUser, Parts: []protocol.Part{protocol.NewTextPart(text)}},
+	}
+}
+
+// newTestManager returns the task manager of a server hosting the executor.
+func newTestManager(executor AgentExecutorFunc, opts ...Option) *taskManager {
+	card := protocol.AgentCard{Name: "test", Url: "http://localhost", Version: "1.0.0"}
+	return NewA2AServerWithExecutor(card, executor, opts...).handler.(*taskManager)
+}
+
+// waitDone waits until the task has no running execution anymore.
+func waitDone(t *testing.T, m *taskManager, taskID string) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		m.mu.Lock()
+		_, running := m.running[taskID]
+		m.mu.Unlock()
+
+		if !running {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("task [%s] still running", taskID)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWaitForInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		followUp  bool
+		cancel    bool
+		wantErr   error
+		wantState protocol.TaskState
+	}{
+		{name: "resumed by the follow-up", followUp: true, wantState: protocol.TaskStateCompleted},
+		{name: "input timeout", wantErr: ErrInputTimeout, wantState: protocol.TaskStateFailed},
+		{name: "canceled", cancel: true, wantErr: context.Canceled, wantState: protocol.TaskStateCanceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waited := make(chan error, 1)
+			m := newTestManager(func(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+				if err := updater.RequireInput(ctx); err != nil {
+					return err
+				}
+
+				_, err := updater.WaitForInput(ctx)
+				waited <- err
+				return err
+			}, WithInputTimeout(100*time.Millisecond))
+
+			ctx := context.Background()
+			task, err := m.SendTask(ctx, sendParams("t1", "hello"))
+			if err != nil || task.Status.State != protocol.TaskStateInputRequired {
+				t.Fatalf("SendTask = %v, %v, want input-required", task, err)
+			}
+
+			if tt.followUp {
+				if _, err := m.SendTask(ctx, sendParams("t1", "more")); err != nil {
+					t.Fatalf("follow-up SendTask = %v", err)
+				}
+			}
+
+			if tt.cancel {
+				if _, err := m.CancelTask(ctx, sendParams("t1", "")); err != nil {
+					t.Fatalf("CancelTask = %v", err)
+				}
+			}
+
+			select {
+			case err := <-waited:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("WaitForInput = %v, want %v", err, tt.wantErr)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("WaitForInput still waiting")
+			}
+
+			waitDone(t, m, "t1")
+			task, err = m.GetTask(ctx, sendParams("t1", ""))
+			if err != nil || task.Status.State != tt.wantState {
+				t.Fatalf("task = %v, %v, want %s", task.Status.State, err, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestExecutorLifecycle(t *testing.T) {
+	started := make(chan struct{})
+	proceed := make(chan struct{})
+
+	m := newTestManager(func(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+		close(started)
+		<-proceed
+
+		if err := updater.Working(ctx, protocol.NewTextPart("thinking")); err != nil {
+			return err
+		}
+
+		if err := updater.AddArtifact(ctx, protocol.Artifact{Parts: []protocol.Part{protocol.NewTextPart("answer")}}); err != nil {
+			return err
+		}
+
+		return updater.Complete(ctx)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// every subscriber gets every update of the execution, in order.
+	results := make(chan []string, 2)
+	collect := func(events chan any) {
+		var got []string
+		for event := range events {
+			switch e := event.(type) {
+			case *protocol.TaskStatusUpdateEvent:
+				// the working status set by the server before Execute, missed by late subscribers.
+				if e.Status.State == protocol.TaskStateWorking && e.Status.Message == nil {
+					continue
+				}
+
+				got = append(got, string(e.Status.State))
+				if e.Final != e.Status.State.IsTerminal() {
+					got = append(got, "unexpected final")
+				}
+			case *protocol.TaskArtifactUpdateEvent:
+				got = append(got, "artifact")
+			}
+		}
+
+		results <- got
+	}
+
+	first, err := m.SubscribeTask(ctx, sendParams("t1", "hello"))
+	if err != nil {
+		t.Fatalf("SubscribeTask = %v", err)
+	}
+	go collect(first)
+
+	<-started
+	second, err := m.ResubscribeTask(ctx, sendParams("t1", ""))
+	if err != nil {
+		t.Fatalf("ResubscribeTask = %v", err)
+	}
+	go collect(second)
+	close(proceed)
+
+	for i := 0; i < 2; i++ {
+		if got, want := <-results, []string{"working", "artifact", "completed"}; !slices.Equal(got, want) {
+			t.Fatalf("subscriber got %v, want %v", got, want)
+		}
+	}
+
+	waitDone(t, m, "t1")
+	task, err := m.GetTask(ctx, sendParams("t1", ""))
+	if err != nil || task.Status.State != protocol.TaskStateCompleted || len(task.Artifacts) != 1 || len(task.History) != 2 {
+		t.Fatalf("task = %+v, %v, want completed with the artifact and both messages", task, err)
+	}
+}
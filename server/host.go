@@ -2,7 +2,6 @@ package server
 
 import (
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
 	"time"
@@ -12,9 +11,17 @@ import (
 
 type (
 	StandardA2AServerHost struct {
-		addr string
+		addr              string
+		keepAliveInterval time.Duration
+		retry             time.Duration
+		cardCacheControl  string
+		auth              Authenticator
+		middlewares       []Middleware
 	}
 
+	// Middleware wraps the handlers of a host, e.g. for logging or tracing.
+	Middleware func(next http.Handler) http.Handler
+
 	JsonRpcRaw struct {
 		Version string             `json:"jsonrpc"`
 		ID      uint64             `json:"id"`
@@ -25,34 +32,57 @@ type (
 
 // Host implements IA2AServerHost.
 func (s *StandardA2AServerHost) Host(server *A2AServer) error {
-	http.HandleFunc("/.well-known/agent.json", func(resp http.ResponseWriter, req *http.Request) {
-		defer req.Body.Close()
+	card, rpc := s.handlers(server)
+	http.Handle("/.well-known/agent.json", s.wrap(card))
+	http.Handle("/", s.wrap(rpc))
+	return http.ListenAndServe(s.addr, nil)
+}
 
-		agentCard := server.AgentCard()
-		agentCardJson, err := json.Marshal(agentCard)
-		if err != nil {
-			// TODO: handle error
-		}
+// handlers returns the handlers of the agent card and of the JSON-RPC endpoint of the server.
+func (s *StandardA2AServerHost) handlers(server *A2AServer) (card, rpc http.Handler) {
+	card = &cardHandler{
+		server:       server,
+		cacheControl: s.cardCacheControl,
+		auth:         s.auth,
+	}
 
-		resp.WriteHeader(http.StatusOK)
-		resp.Header().Set("Content-Type", "application/json")
-		resp.Write(agentCardJson)
-	})
+	rpc = &standardHander{
+		server:            server,
+		keepAlive:         s.keepAliveInterval > 0,
+		keepAliveInterval: s.keepAliveInterval,
+		retry:             s.retry,
+		auth:              s.auth,
+	}
 
-	http.Handle("/", &standardHander{server: server})
-	return http.ListenAndServe(s.addr, nil)
+	return card, rpc
+}
+
+// wrap applies the middlewares to h, the first one being the outermost.
+func (s *StandardA2AServerHost) wrap(h http.Handler) http.Handler {
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		h = s.middlewares[i](h)
+	}
+
+	return h
 }
 
 type standardHander struct {
 	server            *A2AServer
 	keepAlive         bool
 	keepAliveInterval time.Duration
+	retry             time.Duration
+	auth              Authenticator
 }
 
 // ServeHTTP implements http.Handler.
 func (s *standardHander) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	defer req.Body.Close()
 
+	req, ok := authenticate(w, req, s.auth)
+	if !ok {
+		return
+	}
+
 	body, err := io.ReadAll(req.Body)
 
 	// if request body cannot read, return 400
@@ -88,92 +118,98 @@ func (s *standardHander) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 
 	// route by 'method' in rpc
-	if isStreaming(raw.Method) {
-		flusher, ok := w.(http.Flusher)
-		if !ok {
-			http.Error(w, "Streaming unsupported on server", http.StatusInternalServerError)
+	if s.server.isStreaming(raw.Method) {
+		// answer an unsupported stream with a plain response, rather than opening the stream.
+		if err := s.server.checkMethod(req.Context(), raw.Method); err != nil {
+			response(w, s.server.handleError(raw.ID, err))
 			return
 		}
 
-		respCh := make(chan *protocol.JsonRpcResponse, 10)
-		go func() {
-			s.server.HandleStreaming(req.Context(), raw, respCh)
-		}()
+		s.serveStreaming(w, req, raw)
+		return
+	}
 
-		eventCh := make(chan string, 10)
+	resp := s.server.HandleMessage(req.Context(), raw)
+	response(w, resp)
+}
 
-		go func() {
-			for resp := range respCh {
-				data, err := json.Marshal(resp)
-				if err == nil {
-					msg := fmt.Sprintf("event: message\ndata: %s\n\n", data)
-					eventCh <- msg
-				}
-			}
+// serveStreaming writes the task stream as Server-Sent Events.
+func (s *standardHander) serveStreaming(w http.ResponseWriter, req *http.Request, raw *JsonRpcRaw) {
+	sse, err := NewSSEWriter(w)
+	if err != nil {
+		http.Error(w, "Streaming unsupported on server", http.StatusInternalServerError)
+		return
+	}
 
-			// no more response, close the event channel
-			close(eventCh)
-		}()
+	// no write is allowed once ServeHTTP returns.
+	defer sse.Close()
 
-		if s.keepAlive {
-			go func() {
-				ticker := time.NewTicker(s.keepAliveInterval)
+	if s.retry > 0 {
+		sse.WriteRetry(s.retry)
+	}
 
-				defer func() {
-					// if eventCh is closed, ping msg will panic.
-					// recover it but ignore.
-					if r := recover(); r != nil {
-						// ignore panic
-					}
+	// unbuffered, the events are buffered by the backpressure policy of the stream.
+	respCh := make(chan StreamEvent)
+	go s.server.HandleStreaming(req.Context(), raw, req.Header.Get("Last-Event-ID"), respCh)
 
-					ticker.Stop()
-				}()
+	if s.keepAlive {
+		done := make(chan struct{})
+		defer close(done)
 
-				for {
-					select {
-					case <-ticker.C:
-						//: ping - 2025-03-27 07:44:38.682659+00:00
-						eventCh <- fmt.Sprintf(":ping - %s\n\n", time.Now().Format(time.RFC3339))
-					case <-req.Context().Done():
+		go func() {
+			ticker := time.NewTicker(s.keepAliveInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					//: ping - 2025-03-27T07:44:38Z
+					if sse.WriteComment(" ping - "+time.Now().Format(time.RFC3339)) != nil {
 						return
 					}
-				}
-			}()
-		}
-
-		for {
-			select {
-			case event, more := <-eventCh:
-				fmt.Fprint(w, event)
-				flusher.Flush()
-
-				if !more {
+				case <-done:
 					return
 				}
-			case <-req.Context().Done():
-				return
 			}
-		}
+		}()
 	}
 
-	resp := s.server.HandleMessage(req.Context(), raw)
-	response(w, resp)
+	// HandleStreaming closes respCh once the stream ends or the request is done.
+	for resp := range respCh {
+		data, err := json.Marshal(resp.Response)
+		if err != nil {
+			continue
+		}
+
+		err = sse.WriteEvent(SSEEvent{
+			ID:    resp.ID,
+			Event: "message",
+			Data:  data,
+		})
+		if err != nil {
+			return
+		}
+	}
 }
 
 func response(w http.ResponseWriter, resp *protocol.JsonRpcResponse) {
 	// write json-rpc response to http-response
 	// http, as the "transport" layer for json-rpc, the status code is 200 even if error occurs in RPC.
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	w.Write(resp.ToByte())
 }
 
-func isStreaming(method protocol.A2AMethod) bool {
-	return method == protocol.MethodSubscribeTask || method == protocol.MethodResubscribeTask
-}
+func NewA2AHost(addr string, opts ...HostOption) *StandardA2AServerHost {
+	h := &StandardA2AServerHost{
+		addr:             addr,
+		cardCacheControl: "public, max-age=300",
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
 
-func NewA2AHost(addr string) *StandardA2AServerHost {
-	return &StandardA2AServerHost{addr: addr}
+	return h
 }
 
 var _ http.Handler = (*standardHander)(nil)
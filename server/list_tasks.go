@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+type (
+	// TaskScope reports whether the task is visible to the caller of ctx in tasks/list,
+	// see [PrincipalFromContext] and [WithListTasksMethod]. owner is the subject of the principal
+	// who created the task, empty if created anonymously.
+	TaskScope func(ctx context.Context, task *protocol.Task, owner string) bool
+
+	// listCursor is the position after the last task of a page, tasks being ordered
+	// by their last update, the most recent first, then by id.
+	listCursor struct {
+		Updated string `json:"u"`
+		ID      string `json:"i"`
+	}
+)
+
+// OwnTasks is the default [TaskScope]: a principal sees the tasks it created,
+// anonymous callers see the tasks created anonymously.
+func OwnTasks(ctx context.Context, task *protocol.Task, owner string) bool {
+	return owner == subject(ctx)
+}
+
+// ListTasks implements protocol.ITaskLister, if the task store implements [IListableTaskStore].
+func (m *taskManager) ListTasks(ctx context.Context, params *protocol.ListTasksParams) (*protocol.ListTasksResult, error) {
+	store, ok := m.store.(IListableTaskStore)
+	if !ok || m.scope == nil {
+		return nil, protocol.ErrUnsupportedOperation.New().Args(protocol.MethodListTasks)
+	}
+
+	var after *listCursor
+	if params.Cursor != "" {
+		after = new(listCursor)
+		raw, err := base64.RawURLEncoding.DecodeString(params.Cursor)
+		if err != nil || json.Unmarshal(raw, after) != nil {
+			return nil, protocol.ErrInvalidCursor.New().Args(params.Cursor)
+		}
+	}
+
+	// the store calls match under its lock, which m.mu must not be taken under:
+	// the task manager saves tasks holding m.mu.
+	owners := m.ownersSnapshot()
+	tasks, err := store.ListTasks(ctx, func(task *protocol.Task) bool {
+		_, ok := owners[task.ID]
+		return ok && matchTask(params, task)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tasks = slices.DeleteFunc(tasks, func(task *protocol.Task) bool {
+		return !m.scope(ctx, task, owners[task.ID])
+	})
+
+	slices.SortFunc(tasks, func(a, b *protocol.Task) int {
+		return compareListed(a, b)
+	})
+
+	start := 0
+	if after != nil {
+		pos := &protocol.Task{ID: after.ID, Status: protocol.TaskStatus{Timestamp: &after.Updated}}
+		start = sort.Search(len(tasks), func(i int) bool {
+			return compareListed(tasks[i], pos) > 0
+		})
+	}
+
+	size := 50
+	if params.PageSize != nil && *params.PageSize > 0 {
+		size = min(*params.PageSize, 100)
+	}
+
+	tasks = tasks[start:]
+	ret := &protocol.ListTasksResult{Tasks: make([]*protocol.Task, 0, min(size, len(tasks)))}
+	for _, task := range tasks[:min(size, len(tasks))] {
+		trimHistory(task, params.HistoryLength)
+		ret.Tasks = append(ret.Tasks, task)
+	}
+
+	if len(tasks) > size {
+		last := tasks[size-1]
+		raw, _ := json.Marshal(listCursor{Updated: updatedAt(last), ID: last.ID})
+		ret.NextCursor = base64.RawURLEncoding.EncodeToString(raw)
+	}
+
+	return ret, nil
+}
+
+// compareListed orders the tasks by their last update, the most recent first, then by id.
+// Timestamps set by the task manager are UTC in [protocol.TimestampFormat], they sort as strings.
+func compareListed(a, b *protocol.Task) int {
+	if c := strings.Compare(updatedAt(b), updatedAt(a)); c != 0 {
+		return c
+	}
+
+	return strings.Compare(a.ID, b.ID)
+}
+
+func updatedAt(task *protocol.Task) string {
+	if task.Status.Timestamp == nil {
+		return ""
+	}
+
+	return *task.Status.Timestamp
+}
+
+// matchTask reports whether the task matches every filter of params.
+func matchTask(params *protocol.ListTasksParams, task *protocol.Task) bool {
+	if params.SessionID != nil && task.SessionID != *params.SessionID {
+		return false
+	}
+
+	if len(params.States) > 0 && !slices.Contains(params.States, task.Status.State) {
+		return false
+	}
+
+	if params.UpdatedAfter != nil || params.UpdatedBefore != nil {
+		updated, err := time.Parse(protocol.TimestampFormat, updatedAt(task))
+		if err != nil {
+			return false
+		}
+
+		if params.UpdatedAfter != nil && updated.Before(*params.UpdatedAfter) {
+			return false
+		}
+
+		if params.UpdatedBefore != nil && updated.After(*params.UpdatedBefore) {
+			return false
+		}
+	}
+
+	for k, v := range params.Metadata {
+		tv, ok := task.Metadata[k]
+		if !ok || (v != nil && !reflect.DeepEqual(v, tv)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ownersSnapshot returns the subject of the principal who created each task, for the tasks
+// created since the server started.
+func (m *taskManager) ownersSnapshot() map[string]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return maps.Clone(m.owners)
+}
+
+// trimHistory keeps the last historyLength messages, and status changes, of the task.
+func trimHistory(task *protocol.Task, historyLength *int) {
+	if historyLength == nil {
+		return
+	}
+
+	n := max(*historyLength, 0)
+	if len(task.History) > n {
+		task.History = task.History[len(task.History)-n:]
+	}
+
+	if len(task.StatusHistory) > n {
+		task.StatusHistory = task.StatusHistory[len(task.StatusHistory)-n:]
+	}
+}
+
+var _ protocol.ITaskLister = (*taskManager)(nil)
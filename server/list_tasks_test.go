@@ -0,0 +1,199 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+// listAll follows the cursors of tasks/list, returning the ids of every page.
+func listAll(t *testing.T, m *taskManager, ctx context.Context, params protocol.ListTasksParams) [][]string {
+	t.Helper()
+
+	var pages [][]string
+	for {
+		ret, err := m.ListTasks(ctx, &params)
+		if err != nil {
+			t.Fatalf("ListTasks = %v", err)
+		}
+
+		var page []string
+		for _, task := range ret.Tasks {
+			page = append(page, task.ID)
+		}
+
+		pages = append(pages, page)
+		if ret.NextCursor == "" {
+			return pages
+		}
+
+		params.Cursor = ret.NextCursor
+	}
+}
+
+func TestListTasksPages(t *testing.T) {
+	m := newTestManager(completeTask, WithListTasksMethod(nil))
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		if _, err := m.SendTask(ctx, sendParams(fmt.Sprintf("t%d", i), "hello")); err != nil {
+			t.Fatalf("SendTask = %v", err)
+		}
+
+		// timestamps have a millisecond precision.
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	tests := []struct {
+		name     string
+		pageSize int
+		want     int
+	}{
+		{name: "one page", pageSize: 10, want: 1},
+		{name: "exact pages", pageSize: 5, want: 1},
+		{name: "several pages", pageSize: 2, want: 3},
+		{name: "single task pages", pageSize: 1, want: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := listAll(t, m, ctx, protocol.ListTasksParams{PageSize: ptr(tt.pageSize)})
+			if len(pages) != tt.want {
+				t.Fatalf("%d pages %v, want %d", len(pages), pages, tt.want)
+			}
+
+			var ids []string
+			for _, page := range pages {
+				ids = append(ids, page...)
+			}
+
+			// most recent first, each task once.
+			if want := []string{"t4", "t3", "t2", "t1", "t0"}; !slices.Equal(ids, want) {
+				t.Fatalf("listed %v, want %v", ids, want)
+			}
+		})
+	}
+
+	if _, err := m.ListTasks(ctx, &protocol.ListTasksParams{Cursor: "nope"}); !protocol.Is(err, protocol.ErrInvalidCursor) {
+		t.Fatalf("ListTasks with an invalid cursor = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestListTasksOwner(t *testing.T) {
+	alice := ContextWithPrincipal(context.Background(), &Principal{Subject: "alice"})
+	bob := ContextWithPrincipal(context.Background(), &Principal{Subject: "bob"})
+	anonymous := context.Background()
+
+	m := newTestManager(completeTask, WithListTasksMethod(nil))
+	for ctx, id := range map[context.Context]string{alice: "alice-task", bob: "bob-task", anonymous: "anonymous-task"} {
+		params := sendParams(id, "hello")
+		params.Metadata = map[string]any{"owner": "client"}
+		if _, err := m.SendTask(ctx, params); err != nil {
+			t.Fatalf("SendTask = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		metadata map[string]any
+		want     []string
+	}{
+		{name: "alice", ctx: alice, want: []string{"alice-task"}},
+		{name: "bob", ctx: bob, want: []string{"bob-task"}},
+		{name: "anonymous", ctx: anonymous, want: []string{"anonymous-task"}},
+		{name: "owner is not filterable", ctx: alice, metadata: map[string]any{"owner": "bob"}},
+		{name: "client metadata is kept", ctx: alice, metadata: map[string]any{"owner": "client"}, want: []string{"alice-task"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := listAll(t, m, tt.ctx, protocol.ListTasksParams{Metadata: tt.metadata})
+			if !slices.Equal(pages[0], tt.want) {
+				t.Fatalf("listed %v, want %v", pages[0], tt.want)
+			}
+		})
+	}
+}
+
+// TestListTasksConcurrentSends lists the tasks while others are sent, which must not deadlock
+// the store and the task manager. Run with -race.
+func TestListTasksConcurrentSends(t *testing.T) {
+	s := NewA2AServerWithExecutor(protocol.AgentCard{Name: "test"}, AgentExecutorFunc(
+		func(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+			for i := 0; i < 10; i++ {
+				if err := updater.Working(ctx); err != nil {
+					return err
+				}
+			}
+
+			return updater.Complete(ctx)
+		}), WithListTasksMethod(nil))
+
+	call := func(id uint64, method protocol.A2AMethod, params any) error {
+		raw, _ := json.Marshal(params)
+		resp := s.HandleMessage(context.Background(), &JsonRpcRaw{
+			Version: protocol.JsonRpcVersion,
+			ID:      id,
+			Method:  method,
+			Params:  raw,
+		})
+		if resp.Error != nil {
+			return fmt.Errorf("%s = %s", method, resp.Error.Message)
+		}
+
+		return nil
+	}
+
+	done := make(chan struct{})
+	errs := make(chan error, 8)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		w := w
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 200; i++ {
+				if err := call(uint64(i), protocol.MethodSendTask, sendParams(fmt.Sprintf("t%d-%d", w, i), "hello")); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 200; i++ {
+				if err := call(uint64(i), protocol.MethodListTasks, protocol.ListTasksParams{}); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("tasks/list and tasks/send deadlocked")
+	}
+
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+type (
+	// MethodHandler handles a custom JSON-RPC method, see [HandleMethod].
+	MethodHandler[P, R any] func(ctx context.Context, params P) (R, error)
+
+	// StreamHandler handles a custom streaming JSON-RPC method, see [HandleStreamMethod].
+	// Every result sent is a message of the stream, send returns false once the client is gone.
+	// The stream ends when the handler returns, with the error as last message if any.
+	StreamHandler[P, R any] func(ctx context.Context, params P, send func(R) bool) error
+
+	// customMethod is a registered method, with its params and result types erased.
+	customMethod struct {
+		unary  func(ctx context.Context, params json.RawMessage) (any, error)
+		stream func(ctx context.Context, params json.RawMessage, send func(any) bool) error
+	}
+
+	// errParams marks the params a custom method cannot decode.
+	errParams struct {
+		err error
+	}
+)
+
+func (e errParams) Error() string {
+	return e.err.Error()
+}
+
+// HandleMethod registers the handler of a custom JSON-RPC method of the server, such as an
+// extension method, served by HandleMessage with params decoded from JSON into P. Params that
+// don't decode are answered with [protocol.ErrJsonRpcParamsParse], the decode error as data.
+// Registering a built-in method, or a method twice, panics.
+func HandleMethod[P, R any](s *A2AServer, method protocol.A2AMethod, handler MethodHandler[P, R]) {
+	s.registerMethod(method, &customMethod{
+		unary: func(ctx context.Context, raw json.RawMessage) (any, error) {
+			params, err := decodeParams[P](raw)
+			if err != nil {
+				return nil, err
+			}
+
+			return handler(ctx, params)
+		},
+	})
+}
+
+// HandleStreamMethod registers the handler of a custom streaming JSON-RPC method of the server,
+// served by HandleStreaming with params decoded from JSON into P. Hosts stream it as Server-Sent Events.
+// Registering a built-in method, or a method twice, panics.
+func HandleStreamMethod[P, R any](s *A2AServer, method protocol.A2AMethod, handler StreamHandler[P, R]) {
+	s.registerMethod(method, &customMethod{
+		stream: func(ctx context.Context, raw json.RawMessage, send func(any) bool) error {
+			params, err := decodeParams[P](raw)
+			if err != nil {
+				return err
+			}
+
+			return handler(ctx, params, func(r R) bool {
+				return send(r)
+			})
+		},
+	})
+}
+
+func (s *A2AServer) registerMethod(method protocol.A2AMethod, m *customMethod) {
+	switch method {
+	case protocol.MethodSendTask, protocol.MethodGetTask, protocol.MethodCancelTask,
+		protocol.MethodSetTaskPushNotifications, protocol.MethodGetTaskPushNotifications,
+		protocol.MethodSubscribeTask, protocol.MethodResubscribeTask, protocol.MethodListSessionTasks,
+		protocol.MethodListTasks:
+		panic(fmt.Sprintf("a2a: method [%s] is built-in", method))
+	}
+
+	s.methodsMu.Lock()
+	defer s.methodsMu.Unlock()
+
+	if _, ok := s.methods[method]; ok {
+		panic(fmt.Sprintf("a2a: method [%s] is already registered", method))
+	}
+
+	if s.methods == nil {
+		s.methods = make(map[protocol.A2AMethod]*customMethod)
+	}
+
+	s.methods[method] = m
+}
+
+// method returns the custom method, nil if not registered.
+func (s *A2AServer) method(method protocol.A2AMethod) *customMethod {
+	s.methodsMu.RLock()
+	defer s.methodsMu.RUnlock()
+
+	return s.methods[method]
+}
+
+// isStreaming reports whether the method is answered with a stream, by HandleStreaming.
+func (s *A2AServer) isStreaming(method protocol.A2AMethod) bool {
+	if method == protocol.MethodSubscribeTask || method == protocol.MethodResubscribeTask {
+		return true
+	}
+
+	m := s.method(method)
+	return m != nil && m.stream != nil
+}
+
+// handleCustom answers a unary custom method.
+func (s *A2AServer) handleCustom(ctx context.Context, m *customMethod, raw *JsonRpcRaw) *protocol.JsonRpcResponse {
+	ret, err := m.unary(ctx, raw.Params)
+	if err != nil {
+		return s.handleError(raw.ID, err)
+	}
+
+	return s.response(raw.ID, ret)
+}
+
+// streamCustom answers a streaming custom method, send returns false once the client is gone.
+func (s *A2AServer) streamCustom(ctx context.Context, m *customMethod, raw *JsonRpcRaw, send func(StreamEvent) bool) {
+	err := m.stream(ctx, raw.Params, func(ret any) bool {
+		return send(StreamEvent{Response: s.response(raw.ID, ret)})
+	})
+
+	if err != nil {
+		send(StreamEvent{Response: s.handleError(raw.ID, err)})
+	}
+}
+
+// decodeParams decodes the params of a custom method, absent params being the zero P.
+func decodeParams[P any](raw json.RawMessage) (P, error) {
+	var params P
+	if len(raw) == 0 || string(raw) == "null" {
+		return params, nil
+	}
+
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return params, errParams{err: err}
+	}
+
+	return params, nil
+}
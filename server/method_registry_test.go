@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+func TestHandleMethodParams(t *testing.T) {
+	type echoParams struct {
+		Text string `json:"text"`
+	}
+
+	s := NewA2AServerWithExecutor(protocol.AgentCard{Name: "test"}, AgentExecutorFunc(completeTask))
+	HandleMethod(s, "test/echo", func(ctx context.Context, params echoParams) (string, error) {
+		return params.Text, nil
+	})
+
+	tests := []struct {
+		name     string
+		params   string
+		want     string
+		wantCode int
+		wantData string
+	}{
+		{name: "params", params: `{"text":"hello"}`, want: `"hello"`},
+		{name: "no params", want: `""`},
+		{name: "invalid params", params: `{"text":1}`, wantCode: protocol.CodeJSONParse, wantData: "cannot unmarshal number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := s.HandleMessage(context.Background(), &JsonRpcRaw{
+				Version: protocol.JsonRpcVersion,
+				ID:      1,
+				Method:  "test/echo",
+				Params:  json.RawMessage(tt.params),
+			})
+
+			if tt.wantCode == 0 {
+				got, _ := json.Marshal(resp.Result)
+				if resp.Error != nil || string(got) != tt.want {
+					t.Fatalf("response = %s, %v, want %s", got, resp.Error, tt.want)
+				}
+
+				return
+			}
+
+			if resp.Error == nil {
+				t.Fatalf("response = %v, want code %d", resp.Result, tt.wantCode)
+			}
+
+			data, _ := resp.Error.Data.(string)
+			if resp.Error.Code != tt.wantCode || !strings.Contains(data, tt.wantData) {
+				t.Fatalf("error = %+v, want code %d with data %q", resp.Error, tt.wantCode, tt.wantData)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+type (
+	// MultiA2AServerHost hosts several servers under one listener, each mounted under a path prefix
+	// or a virtual host, with its own card at '<prefix>/.well-known/agent.json' and its own
+	// JSON-RPC endpoint at '<prefix>'. The host options, such as middlewares, are shared.
+	//
+	// The root '/' lists the hosted agents, see [HostedAgent], unless a server is mounted there.
+	MultiA2AServerHost struct {
+		settings StandardA2AServerHost
+
+		mu     sync.Mutex
+		mounts []*mount
+	}
+
+	// HostedAgent is an entry of the index of a [MultiA2AServerHost].
+	HostedAgent struct {
+		// Virtual host of the agent, empty if mounted under a path prefix.
+		Host string `json:"host,omitempty"`
+
+		// Path of the JSON-RPC endpoint of the agent.
+		Path string `json:"path"`
+
+		// Path of the agent card.
+		CardPath string `json:"cardPath"`
+
+		// The public card of the agent.
+		Card protocol.AgentCard `json:"card"`
+	}
+
+	mount struct {
+		host   string
+		prefix string
+		server *A2AServer
+	}
+)
+
+// NewMultiA2AHost returns a host of the servers mounted by [MultiA2AServerHost.Mount]
+// and [MultiA2AServerHost.MountVirtualHost], listening on addr.
+func NewMultiA2AHost(addr string, opts ...HostOption) *MultiA2AServerHost {
+	return &MultiA2AServerHost{settings: *NewA2AHost(addr, opts...)}
+}
+
+// Mount hosts the server under the path prefix, e.g. '/recipes'. Mounting two servers at the same
+// place makes [MultiA2AServerHost.Handler] panic, as [http.ServeMux.Handle].
+func (h *MultiA2AServerHost) Mount(prefix string, server *A2AServer) {
+	h.add(&mount{prefix: "/" + strings.Trim(prefix, "/"), server: server})
+}
+
+// MountVirtualHost hosts the server at the root of the virtual host, e.g. 'recipes.example.com'.
+// Requests for the virtual host are not served by the servers mounted under a path prefix.
+func (h *MultiA2AServerHost) MountVirtualHost(host string, server *A2AServer) {
+	h.add(&mount{host: strings.ToLower(host), prefix: "/", server: server})
+}
+
+func (h *MultiA2AServerHost) add(m *mount) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.mounts = append(h.mounts, m)
+}
+
+// Handler returns the handler of the servers mounted so far, wrapped by the middlewares of the host.
+func (h *MultiA2AServerHost) Handler() http.Handler {
+	h.mu.Lock()
+	mounts := append([]*mount(nil), h.mounts...)
+	h.mu.Unlock()
+
+	mux := http.NewServeMux()
+	root := false
+	for _, m := range mounts {
+		card, rpc := h.settings.handlers(m.server)
+		mux.Handle(m.host+m.cardPath(), card)
+
+		// both '/recipes' and '/recipes/', the mux would redirect one to the other.
+		mux.Handle(m.host+m.prefix, rpc)
+		if m.prefix != "/" {
+			mux.Handle(m.host+m.prefix+"/", rpc)
+		}
+
+		root = root || (m.host == "" && m.prefix == "/")
+	}
+
+	if !root {
+		mux.Handle("/", &indexHandler{mounts: mounts})
+	}
+
+	return h.settings.wrap(mux)
+}
+
+// ListenAndServe serves the servers mounted so far.
+func (h *MultiA2AServerHost) ListenAndServe() error {
+	return http.ListenAndServe(h.settings.addr, h.Handler())
+}
+
+// Agents returns the index of the hosted agents.
+func (h *MultiA2AServerHost) Agents() []HostedAgent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return agents(h.mounts)
+}
+
+func (m *mount) cardPath() string {
+	return strings.TrimSuffix(m.prefix, "/") + "/.well-known/agent.json"
+}
+
+func agents(mounts []*mount) []HostedAgent {
+	ret := make([]HostedAgent, 0, len(mounts))
+	for _, m := range mounts {
+		ret = append(ret, HostedAgent{
+			Host:     m.host,
+			Path:     m.prefix,
+			CardPath: m.cardPath(),
+			Card:     m.server.AgentCard(),
+		})
+	}
+
+	return ret
+}
+
+// indexHandler lists the hosted agents at '/', other paths are not found.
+type indexHandler struct {
+	mounts []*mount
+}
+
+// ServeHTTP implements http.Handler.
+func (h *indexHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/" {
+		http.NotFound(w, req)
+		return
+	}
+
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := json.Marshal(struct {
+		Agents []HostedAgent `json:"agents"`
+	}{Agents: agents(h.mounts)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+var _ http.Handler = (*indexHandler)(nil)
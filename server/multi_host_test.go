@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+// namedServer returns a server whose agent answers every task with its name.
+func namedServer(name string) *A2AServer {
+	card := protocol.AgentCard{Name: name, Url: "http://localhost", Version: "1.0.0"}
+	return NewA2AServerWithExecutor(card, AgentExecutorFunc(
+		func(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+			return updater.Complete(ctx, protocol.NewTextPart(name))
+		}))
+}
+
+// serve answers the request of the host, with the body if any.
+func serve(h http.Handler, method, host, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Host = host
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestMultiHostRouting(t *testing.T) {
+	h := NewMultiA2AHost(":0")
+	h.Mount("/recipes", namedServer("recipes"))
+	h.Mount("travel/", namedServer("travel"))
+	h.MountVirtualHost("Weather.example.com", namedServer("weather"))
+	handler := h.Handler()
+
+	// each case sends its own task, the cases sharing a server.
+	send := `{"jsonrpc":"2.0","id":1,"method":"tasks/send","params":{"id":"%d","message":{"role":"user","parts":[{"type":"text","text":"hi"}]}}}`
+
+	tests := []struct {
+		name      string
+		host      string
+		path      string
+		wantAgent string
+		wantCode  int
+	}{
+		{name: "prefix", host: "agents.example.com", path: "/recipes", wantAgent: "recipes"},
+		{name: "prefix with slash", host: "agents.example.com", path: "/recipes/", wantAgent: "recipes"},
+		{name: "prefix normalized", host: "agents.example.com", path: "/travel", wantAgent: "travel"},
+		{name: "virtual host", host: "weather.example.com", path: "/", wantAgent: "weather"},
+		{name: "prefix not served on the virtual host", host: "weather.example.com", path: "/recipes", wantAgent: "weather"},
+		{name: "unknown agent", host: "agents.example.com", path: "/unknown", wantCode: http.StatusNotFound},
+		{name: "unknown host", host: "unknown.example.com", path: "/weather", wantCode: http.StatusNotFound},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(handler, http.MethodPost, tt.host, tt.path, fmt.Sprintf(send, i))
+			if tt.wantCode != 0 {
+				if w.Code != tt.wantCode {
+					t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+				}
+
+				return
+			}
+
+			var resp struct {
+				Result *protocol.Task `json:"result"`
+			}
+
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Result == nil {
+				t.Fatalf("response %s: %v", w.Body, err)
+			}
+
+			if msg := resp.Result.Status.Message; msg == nil || msg.Parts[0].Text != tt.wantAgent {
+				t.Fatalf("answered by %+v, want %s", msg, tt.wantAgent)
+			}
+		})
+	}
+}
+
+func TestMultiHostCards(t *testing.T) {
+	h := NewMultiA2AHost(":0")
+	h.Mount("/recipes", namedServer("recipes"))
+	h.MountVirtualHost("weather.example.com", namedServer("weather"))
+	handler := h.Handler()
+
+	w := serve(handler, http.MethodGet, "agents.example.com", "/", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("index status = %d, want 200", w.Code)
+	}
+
+	var index struct {
+		Agents []HostedAgent `json:"agents"`
+	}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &index); err != nil {
+		t.Fatalf("index %s: %v", w.Body, err)
+	}
+
+	want := []HostedAgent{
+		{Path: "/recipes", CardPath: "/recipes/.well-known/agent.json", Card: protocol.AgentCard{Name: "recipes"}},
+		{Host: "weather.example.com", Path: "/", CardPath: "/.well-known/agent.json", Card: protocol.AgentCard{Name: "weather"}},
+	}
+
+	if len(index.Agents) != len(want) {
+		t.Fatalf("index lists %d agents, want %d", len(index.Agents), len(want))
+	}
+
+	for i, agent := range index.Agents {
+		if agent.Host != want[i].Host || agent.Path != want[i].Path || agent.CardPath != want[i].CardPath || agent.Card.Name != want[i].Card.Name {
+			t.Fatalf("agent %d = %+v, want %+v", i, agent, want[i])
+		}
+
+		// the card advertised is served there.
+		host := agent.Host
+		if host == "" {
+			host = "agents.example.com"
+		}
+
+		w := serve(handler, http.MethodGet, host, agent.CardPath, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("card of %s = %d, want 200", agent.Card.Name, w.Code)
+		}
+
+		var card protocol.AgentCard
+		if err := json.Unmarshal(w.Body.Bytes(), &card); err != nil || card.Name != agent.Card.Name {
+			t.Fatalf("card at %s = %s, want %s", agent.CardPath, w.Body, agent.Card.Name)
+		}
+	}
+
+	if w := serve(handler, http.MethodGet, "agents.example.com", "/unknown/.well-known/agent.json", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("card of an unknown agent = %d, want 404", w.Code)
+	}
+
+	// a server mounted at the root replaces the index.
+	h.Mount("/", namedServer("root"))
+	w = serve(h.Handler(), http.MethodGet, "agents.example.com", "/.well-known/agent.json", "")
+
+	var card protocol.AgentCard
+	if err := json.Unmarshal(w.Body.Bytes(), &card); err != nil || card.Name != "root" {
+		t.Fatalf("root card = %s, want root", w.Body)
+	}
+}
@@ -1 +1,209 @@
 package server
+
+import "time"
+
+type (
+	// Option configures an [A2AServer].
+	Option func(*A2AServer)
+
+	// HostOption configures a [StandardA2AServerHost].
+	HostOption func(*StandardA2AServerHost)
+)
+
+// WithPushURLPolicy sets the policy validating push notification urls, see [PushURLPolicy].
+// Default is [DefaultPushURLPolicy], nil disables the validation.
+func WithPushURLPolicy(policy *PushURLPolicy) Option {
+	return func(s *A2AServer) {
+		s.pushPolicy = policy
+	}
+}
+
+// WithEventLog sets how many events of each task are retained for replay on tasks/resubscribe,
+// and how long the events of a task are retained after its stream ends.
+// Default is 100 events for 10 minutes.
+func WithEventLog(capacity int, retention time.Duration) Option {
+	return func(s *A2AServer) {
+		s.events = newEventLog(capacity, retention)
+	}
+}
+
+// WithKeepAlive makes streams send a ping comment every interval, so idle connections are not
+// closed by proxies. Default is disabled.
+func WithKeepAlive(interval time.Duration) HostOption {
+	return func(h *StandardA2AServerHost) {
+		h.keepAliveInterval = interval
+	}
+}
+
+// WithRetryHint sends the client the time to wait before reconnecting a dropped stream.
+// Default is not sent, leaving it to the client.
+func WithRetryHint(retry time.Duration) HostOption {
+	return func(h *StandardA2AServerHost) {
+		h.retry = retry
+	}
+}
+
+// WithCardCacheControl sets the Cache-Control header of the agent card.
+// Default is 'public, max-age=300', empty sends none.
+func WithCardCacheControl(value string) HostOption {
+	return func(h *StandardA2AServerHost) {
+		h.cardCacheControl = value
+	}
+}
+
+// WithAuthenticator authenticates every request, the principal is carried by the request context,
+// see [PrincipalFromContext]. Default is every request anonymous.
+func WithAuthenticator(auth Authenticator) HostOption {
+	return func(h *StandardA2AServerHost) {
+		h.auth = auth
+	}
+}
+
+// WithMiddleware wraps every handler of the host with the middlewares, the first one being the outermost.
+func WithMiddleware(middlewares ...Middleware) HostOption {
+	return func(h *StandardA2AServerHost) {
+		h.middlewares = append(h.middlewares, middlewares...)
+	}
+}
+
+// WithBackpressure sets the policy applied to streams whose client doesn't keep up with the events.
+// Default is [BackpressureDropStatus] with a buffer of 16 events and a timeout of 30 seconds.
+func WithBackpressure(policy BackpressurePolicy) Option {
+	return func(s *A2AServer) {
+		s.backpressure = policy
+	}
+}
+
+// WithInputTimeout bounds how long [TaskUpdater.WaitForInput] waits for the next message of a task,
+// the executor then gets [ErrInputTimeout]. Default is 1 hour, zero waits until the task is canceled.
+func WithInputTimeout(timeout time.Duration) Option {
+	return func(s *A2AServer) {
+		s.inputTimeout = timeout
+	}
+}
+
+// WithTaskStore sets where the tasks of an [AgentExecutor] are kept. Default is [NewMemoryTaskStore].
+func WithTaskStore(store ITaskStore) Option {
+	return func(s *A2AServer) {
+		s.store = store
+	}
+}
+
+// WithPushNotificationJWT signs the push notifications sent for an [AgentExecutor] with a JWT,
+// carried as a bearer token. Supported algorithms and key types:
+//   - HS256: []byte
+//   - RS256: *rsa.PrivateKey
+//   - ES256: *ecdsa.PrivateKey
+//   - EdDSA: ed25519.PrivateKey
+func WithPushNotificationJWT(alg, kid string, key any) Option {
+	return func(s *A2AServer) {
+		s.pushSigner = &pushSigner{alg: alg, kid: kid, key: key}
+	}
+}
+
+// WithCardSigning signs the agent card served at '/.well-known/agent.json' with a JWS,
+// detached from the body and sent in the [protocol.HeaderCardSignature] header.
+// Supported algorithms and key types are the ones of [WithPushNotificationJWT].
+func WithCardSigning(alg, kid string, key any) Option {
+	return func(s *A2AServer) {
+		s.cardSigner = &cardSigner{alg: alg, kid: kid, key: key}
+	}
+}
+
+// WithExtendedCard serves authenticated principals the card returned by fn instead of the
+// public one, which then advertises it with supportsAuthenticatedExtendedCard.
+// The card of the caller is also the one its requests are checked against, e.g. for the
+// modes of a partner-only skill, which other callers can't request. Principals are set by
+// the [Authenticator] of the host.
+func WithExtendedCard(fn ExtendedCardFunc) Option {
+	return func(s *A2AServer) {
+		s.extendedCard = fn
+	}
+}
+
+// WithSessionIdleTimeout sets how long a session without new tasks or artifacts is kept.
+// Default is 30 minutes.
+func WithSessionIdleTimeout(d time.Duration) Option {
+	return func(s *A2AServer) {
+		s.sessions = newSessionManager(d)
+	}
+}
+
+// WithSessionTasksMethod enables the sessions/tasks extension method, listing the tasks of a session.
+// Callers only find the sessions they created, see [Session].
+func WithSessionTasksMethod() Option {
+	return func(s *A2AServer) {
+		s.sessionTasks = true
+	}
+}
+
+// WithListTasksMethod enables the tasks/list extension method, listing the tasks visible to the caller,
+// see [protocol.ListTasksParams]. scope decides which tasks are visible, default is [OwnTasks].
+// The server records the subject of the principal creating each task as its owner, kept out of
+// the task. Tasks it has not seen created, e.g. left in a persistent store by a previous run, are not listed.
+//
+// For an [AgentExecutor] the task store must implement [IListableTaskStore], as the default one does.
+// Other handlers must implement [protocol.ITaskLister] and enforce the scope themselves.
+func WithListTasksMethod(scope TaskScope) Option {
+	return func(s *A2AServer) {
+		if scope == nil {
+			scope = OwnTasks
+		}
+
+		s.listTasks = true
+		s.taskScope = scope
+	}
+}
+
+// WithWorkerPool bounds the tasks worked on at the same time, queueing or rejecting the others,
+// see [WorkerPoolPolicy]. Default is unbounded.
+//
+// The executions of an [AgentExecutor] are queued in the submitted state, tasks/send returns
+// when they reach a final state as usual. With a [protocol.IA2AProtocol], tasks/send and
+// tasks/sendSubscribe wait in the queue before calling the handler.
+func WithWorkerPool(policy WorkerPoolPolicy) Option {
+	return func(s *A2AServer) {
+		s.pool = newWorkerPool(policy)
+	}
+}
+
+// WithSendMode sets how tasks/send waits for the task, unless chosen by the client with
+// [protocol.MetadataBlocking]. timeout, if positive, bounds the time a blocking tasks/send waits,
+// including the [protocol.MetadataBlockingTimeout] of the client.
+// Default is [SendBlocking] without timeout.
+func WithSendMode(mode SendMode, timeout time.Duration) Option {
+	return func(s *A2AServer) {
+		s.sendMode = mode
+		s.sendTimeout = timeout
+	}
+}
+
+// WithSendDedup sets how long a tasks/send is remembered to recognize its retries, which return
+// the task instead of starting the work again. A retry repeats the [protocol.MetadataIdempotencyKey]
+// of the last send of the task, sends without key are never deduplicated unless [WithMessageDedup]
+// is set. Default is 10 minutes, 0 disables.
+func WithSendDedup(retention time.Duration) Option {
+	return func(s *A2AServer) {
+		s.dedup = nil
+		if retention > 0 {
+			s.dedup = newSendDedup(retention)
+		}
+	}
+}
+
+// WithMessageDedup also recognizes as a retry a tasks/send without [protocol.MetadataIdempotencyKey]
+// repeating the message of the last send of the task. A client sending the same follow-up message
+// twice on purpose, e.g. 'yes', then gets the task instead of a new turn. Default is disabled.
+func WithMessageDedup() Option {
+	return func(s *A2AServer) {
+		s.dedupMessages = true
+	}
+}
+
+// WithRetention sets what is kept of the tasks of an [AgentExecutor], see [RetentionPolicy].
+// Default is everything, forever.
+func WithRetention(policy RetentionPolicy) Option {
+	return func(s *A2AServer) {
+		s.retention = &policy
+	}
+}
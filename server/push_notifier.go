@@ -0,0 +1,221 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/internal/jws"
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+type (
+	// pushNotifier sends the events of a task to its push notification url.
+	// Notifications of a task are sent in order, each retried with backoff.
+	//
+	// Queuing never blocks the task: once the queue of a task is full, its intermediate
+	// notifications are dropped, and the final one makes room by dropping the oldest.
+	pushNotifier struct {
+		client  *http.Client
+		signer  *pushSigner
+		retries int
+		backoff time.Duration
+
+		mu     sync.Mutex
+		queues map[string]*pushQueue
+	}
+
+	// pushQueue is the queue of a task, its notifications are sent until ctx is canceled.
+	pushQueue struct {
+		jobs   chan pushJob
+		ctx    context.Context
+		cancel context.CancelFunc
+	}
+
+	pushSigner struct {
+		alg string
+		kid string
+		key any
+	}
+
+	pushJob struct {
+		config protocol.PushNotificationConfig
+		event  any
+		final  bool
+	}
+)
+
+func newPushNotifier(policy *PushURLPolicy, signer *pushSigner) *pushNotifier {
+	client := &http.Client{Timeout: 10 * time.Second}
+	if policy != nil {
+		client = policy.HTTPClient(10 * time.Second)
+	}
+
+	return &pushNotifier{
+		client:  client,
+		signer:  signer,
+		retries: 3,
+		backoff: time.Second,
+		queues:  make(map[string]*pushQueue),
+	}
+}
+
+// notify queues the event for the task without blocking, final ends the queue of the task.
+func (n *pushNotifier) notify(taskID string, config protocol.PushNotificationConfig, event any, final bool) {
+	n.mu.Lock()
+	queue, ok := n.queues[taskID]
+	if !ok {
+		queue = newPushQueue()
+		n.queues[taskID] = queue
+		go n.run(queue)
+	}
+
+	if final {
+		delete(n.queues, taskID)
+	}
+	n.mu.Unlock()
+
+	queue.put(pushJob{config: config, event: event, final: final})
+}
+
+// cancel drops the pending notifications of the task and stops sending them,
+// e.g. once its push notification url is replaced.
+func (n *pushNotifier) cancel(taskID string) {
+	n.mu.Lock()
+	queue, ok := n.queues[taskID]
+	delete(n.queues, taskID)
+	n.mu.Unlock()
+
+	if ok {
+		queue.cancel()
+	}
+}
+
+func (n *pushNotifier) run(queue *pushQueue) {
+	defer queue.cancel()
+
+	for {
+		var job pushJob
+		select {
+		case job = <-queue.jobs:
+		case <-queue.ctx.Done():
+			return
+		}
+
+		// the id is shared by the retries, so the receiver can deduplicate them.
+		id := newID()
+
+		for attempt := 0; attempt <= n.retries; attempt++ {
+			if attempt > 0 {
+				select {
+				case <-time.After(n.backoff << (attempt - 1)):
+				case <-queue.ctx.Done():
+					return
+				}
+			}
+
+			if err := n.send(queue.ctx, id, job); err == nil {
+				break
+			}
+		}
+
+		if job.final {
+			return
+		}
+	}
+}
+
+func newPushQueue() *pushQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &pushQueue{
+		jobs:   make(chan pushJob, 64),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// put queues the job without blocking. If the queue is full, an intermediate job is dropped,
+// a final job is queued by dropping the oldest ones.
+func (q *pushQueue) put(job pushJob) {
+	for {
+		select {
+		case q.jobs <- job:
+			return
+		default:
+		}
+
+		if !job.final {
+			return
+		}
+
+		select {
+		case <-q.jobs:
+		default:
+		}
+	}
+}
+
+func (n *pushNotifier) send(ctx context.Context, id string, job pushJob) error {
+	body, err := json.Marshal(job.event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.config.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(protocol.HeaderNotificationID, id)
+	if job.config.Token != nil {
+		req.Header.Set(protocol.HeaderNotificationToken, *job.config.Token)
+	}
+
+	if n.signer != nil {
+		token, err := n.signer.sign(body)
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("push notification failed, http-code: %s", resp.Status)
+	}
+
+	return nil
+}
+
+func (s *pushSigner) sign(body []byte) (string, error) {
+	sum := sha256.Sum256(body)
+	claims, err := json.Marshal(map[string]any{
+		"iat":                           time.Now().Unix(),
+		protocol.ClaimRequestBodySHA256: hex.EncodeToString(sum[:]),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return jws.Sign(s.alg, s.kid, s.key, claims)
+}
+
+func newID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
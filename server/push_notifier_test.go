@@ -0,0 +1,91 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+func TestPushNotifierOverflow(t *testing.T) {
+	unblock := make(chan struct{})
+	var (
+		mu       sync.Mutex
+		received []bool
+	)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-unblock
+
+		event := new(protocol.TaskStatusUpdateEvent)
+		json.NewDecoder(req.Body).Decode(event)
+
+		mu.Lock()
+		received = append(received, event.Final)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	n := newPushNotifier(nil, nil)
+	config := protocol.PushNotificationConfig{Url: receiver.URL}
+
+	// the receiver is stuck, queuing must not block the task.
+	start := time.Now()
+	for i := 0; i < 200; i++ {
+		n.notify("t1", config, statusEvent("t1", false), false)
+	}
+	n.notify("t1", config, statusEvent("t1", true), true)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("notify blocked for %s", elapsed)
+	}
+
+	close(unblock)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		got := append([]bool(nil), received...)
+		mu.Unlock()
+
+		if len(got) > 0 && got[len(got)-1] {
+			if len(got) > 66 {
+				t.Errorf("received %d notifications, want at most the queue size and the one in flight", len(got))
+			}
+
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("final notification not received, got %d notifications", len(got))
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPushNotifierCancel(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	before := runtime.NumGoroutine()
+
+	n := newPushNotifier(nil, nil)
+	n.backoff = time.Hour
+	n.notify("t1", protocol.PushNotificationConfig{Url: receiver.URL}, statusEvent("t1", false), false)
+
+	// wait for the first attempt to fail, the retry is waiting for its backoff.
+	time.Sleep(100 * time.Millisecond)
+	n.cancel("t1")
+
+	receiver.CloseClientConnections()
+	n.client.CloseIdleConnections()
+	checkNoLeak(t, before)
+}
@@ -0,0 +1,316 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+// DefaultDeniedCIDRs are the loopback, private, link-local, shared (CGNAT), benchmarking,
+// multicast, reserved and unspecified ranges denied by [DefaultPushURLPolicy], and the
+// IPv6 translation ranges (NAT64, 6to4) embedding an IPv4 address.
+var DefaultDeniedCIDRs = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("255.255.255.255/32"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2002::/16"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// PushURLPolicy decides which [protocol.PushNotificationConfig.Url] an agent accepts,
+// protecting it from being used to reach internal addresses (SSRF).
+//
+// An address is permitted when:
+//   - it matches AllowedCIDRs, or
+//   - it matches no DeniedCIDRs and AllowedCIDRs is empty.
+//
+// The policy is enforced twice: when the config is accepted (see [PushURLPolicy.Validate])
+// and when the notification is sent (see [PushURLPolicy.DialContext]), so a DNS record
+// changed in between (DNS rebinding) cannot redirect notifications to a denied address.
+type PushURLPolicy struct {
+	// Allowed URL schemes. Default is "https" only.
+	AllowedSchemes []string
+
+	// Addresses always permitted, even if matching DeniedCIDRs.
+	AllowedCIDRs []netip.Prefix
+
+	// Addresses denied. See [DefaultDeniedCIDRs].
+	DeniedCIDRs []netip.Prefix
+
+	// If true, the receiver must answer the URL-validation challenge before the config is accepted.
+	// See [protocol.QueryValidationToken].
+	VerifyOwnership bool
+
+	// Timeout of the ownership verification request. Default is 5 seconds.
+	VerifyTimeout time.Duration
+
+	// Resolver used to look up host names. Default is [net.DefaultResolver].
+	Resolver *net.Resolver
+
+	clientOnce sync.Once
+	client     *http.Client
+}
+
+// DefaultPushURLPolicy only accepts https urls that don't resolve to
+// loopback, private or link-local addresses.
+func DefaultPushURLPolicy() *PushURLPolicy {
+	return &PushURLPolicy{
+		AllowedSchemes: []string{"https"},
+		DeniedCIDRs:    slices.Clone(DefaultDeniedCIDRs),
+		VerifyTimeout:  5 * time.Second,
+	}
+}
+
+// Accept validates the url and, if enabled, verifies the receiver owns it.
+// Violations are reported as [protocol.ErrPushNotificationURL].
+func (p *PushURLPolicy) Accept(ctx context.Context, rawURL string) error {
+	if err := p.Validate(ctx, rawURL); err != nil {
+		return err
+	}
+
+	if p.VerifyOwnership {
+		if err := p.verifyOwnership(ctx, rawURL); err != nil {
+			return protocol.ErrPushNotificationURL.New().
+				Args(rawURL, err.Error()).
+				Stack(err)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the scheme of the url and every address its host resolves to.
+// Violations are reported as [protocol.ErrPushNotificationURL].
+func (p *PushURLPolicy) Validate(ctx context.Context, rawURL string) error {
+	reject := func(reason string) error {
+		return protocol.ErrPushNotificationURL.New().Args(rawURL, reason)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return reject("malformed url")
+	}
+
+	if !slices.Contains(p.schemes(), strings.ToLower(u.Scheme)) {
+		return reject(fmt.Sprintf("scheme [%s] not allowed", u.Scheme))
+	}
+
+	if u.User != nil {
+		return reject("user info not allowed")
+	}
+
+	if u.Hostname() == "" {
+		return reject("missing host")
+	}
+
+	addrs, err := p.resolve(ctx, u.Hostname())
+	if err != nil {
+		return reject(err.Error())
+	}
+
+	for _, addr := range addrs {
+		if !p.permitted(addr) {
+			return reject(fmt.Sprintf("address [%s] not allowed", addr))
+		}
+	}
+
+	return nil
+}
+
+// DialContext resolves the address and dials the first permitted ip,
+// so the checked ip is exactly the one connected to.
+func (p *PushURLPolicy) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := p.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{}
+	var lastErr error
+	for _, addr := range addrs {
+		if !p.permitted(addr) {
+			lastErr = fmt.Errorf("address [%s] not allowed for push notification", addr)
+			continue
+		}
+
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no address for host [%s]", host)
+	}
+
+	return nil, lastErr
+}
+
+// HTTPClient returns a client dialing through [PushURLPolicy.DialContext].
+// Proxies and redirects are disabled, as both could bypass the policy.
+// The clients of a policy share its transport, and so its idle connections.
+func (p *PushURLPolicy) HTTPClient(timeout time.Duration) *http.Client {
+	client := *p.httpClient()
+	client.Timeout = timeout
+	return &client
+}
+
+// httpClient returns the client of the policy, built once.
+func (p *PushURLPolicy) httpClient() *http.Client {
+	p.clientOnce.Do(func() {
+		p.client = &http.Client{
+			Transport: &http.Transport{
+				Proxy:               nil,
+				DialContext:         p.DialContext,
+				ForceAttemptHTTP2:   true,
+				TLSHandshakeTimeout: 10 * time.Second,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	})
+
+	return p.client
+}
+
+// verifyOwnership issues the URL-validation challenge: a GET request carrying a random
+// [protocol.QueryValidationToken] that the receiver must echo back.
+func (p *PushURLPolicy) verifyOwnership(ctx context.Context, rawURL string) error {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	token := hex.EncodeToString(buf)
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	query := u.Query()
+	query.Set(protocol.QueryValidationToken, token)
+	u.RawQuery = query.Encode()
+
+	timeout := p.VerifyTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("ownership verification failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ownership verification failed, http-code: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(len(token))+1))
+	if err != nil {
+		return fmt.Errorf("ownership verification failed: %w", err)
+	}
+
+	if strings.TrimSpace(string(body)) != token {
+		return errors.New("ownership verification failed, validation token mismatch")
+	}
+
+	return nil
+}
+
+func (p *PushURLPolicy) schemes() []string {
+	if len(p.AllowedSchemes) == 0 {
+		return []string{"https"}
+	}
+
+	return p.AllowedSchemes
+}
+
+func (p *PushURLPolicy) resolve(ctx context.Context, host string) ([]netip.Addr, error) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return []netip.Addr{addr.Unmap()}, nil
+	}
+
+	resolver := p.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	addrs, err := resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, fmt.Errorf("resolve host [%s] error: %w", host, err)
+	}
+
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no address for host [%s]", host)
+	}
+
+	for i := range addrs {
+		addrs[i] = addrs[i].Unmap()
+	}
+
+	return addrs, nil
+}
+
+// permitted reports whether the policy allows the ip. Zones are ignored, as prefixes never
+// contain a zoned ip: '[fe80::1%eth0]' is checked as 'fe80::1'.
+func (p *PushURLPolicy) permitted(addr netip.Addr) bool {
+	addr = addr.WithZone("")
+	for _, prefix := range p.AllowedCIDRs {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	for _, prefix := range p.DeniedCIDRs {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return len(p.AllowedCIDRs) == 0
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+func TestPushURLPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		ok   bool
+	}{
+		{"public ipv4", "https://93.184.216.34/notify", true},
+		{"public ipv6", "https://[2606:2800:220:1::1]/notify", true},
+		{"http scheme", "http://93.184.216.34/notify", false},
+		{"user info", "https://user@93.184.216.34/notify", false},
+		{"malformed", "https://%zz", false},
+		{"missing host", "https:///notify", false},
+		{"unspecified", "https://0.0.0.0/notify", false},
+		{"private", "https://10.1.2.3/notify", false},
+		{"shared cgnat", "https://100.64.0.1/notify", false},
+		{"loopback", "https://127.0.0.1/notify", false},
+		{"link-local metadata", "https://169.254.169.254/latest", false},
+		{"private 172", "https://172.16.0.1/notify", false},
+		{"private 192", "https://192.168.1.1/notify", false},
+		{"benchmarking", "https://198.18.0.1/notify", false},
+		{"multicast", "https://224.0.0.1/notify", false},
+		{"reserved", "https://240.0.0.1/notify", false},
+		{"broadcast", "https://255.255.255.255/notify", false},
+		{"ipv4-mapped loopback", "https://[::ffff:127.0.0.1]/notify", false},
+		{"ipv6 unspecified", "https://[::]/notify", false},
+		{"ipv6 loopback", "https://[::1]/notify", false},
+		{"nat64", "https://[64:ff9b::7f00:1]/notify", false},
+		{"6to4", "https://[2002:7f00:1::1]/notify", false},
+		{"unique local", "https://[fd00::1]/notify", false},
+		{"ipv6 link-local", "https://[fe80::1]/notify", false},
+		{"zoned ipv6 loopback", "https://[::1%25lo]/notify", false},
+		{"zoned ipv6 link-local", "https://[fe80::1%25eth0]/notify", false},
+		{"ipv6 multicast", "https://[ff02::1]/notify", false},
+	}
+
+	policy := DefaultPushURLPolicy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(context.Background(), tt.url)
+			if tt.ok && err != nil {
+				t.Fatalf("Validate(%s) = %v, want nil", tt.url, err)
+			}
+
+			if !tt.ok && !protocol.Is(err, protocol.ErrPushNotificationURL) {
+				t.Fatalf("Validate(%s) = %v, want ErrPushNotificationURL", tt.url, err)
+			}
+		})
+	}
+}
+
+func TestPushURLPolicyAllowedCIDRs(t *testing.T) {
+	policy := DefaultPushURLPolicy()
+	policy.AllowedSchemes = []string{"http", "https"}
+	policy.AllowedCIDRs = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/24")}
+
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{"http://10.0.0.7/notify", true},
+		{"http://10.0.1.7/notify", false},
+		{"https://93.184.216.34/notify", false},
+	}
+
+	for _, tt := range tests {
+		if err := policy.Validate(context.Background(), tt.url); (err == nil) != tt.ok {
+			t.Errorf("Validate(%s) = %v, want ok %v", tt.url, err, tt.ok)
+		}
+	}
+}
+
+func TestPushURLPolicyDial(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(req.URL.Query().Get(protocol.QueryValidationToken)))
+	}))
+	defer receiver.Close()
+
+	// the loopback receiver is denied when dialing, even if the url was accepted before.
+	denied := DefaultPushURLPolicy()
+	denied.AllowedSchemes = []string{"http"}
+	if _, err := denied.HTTPClient(0).Get(receiver.URL); err == nil {
+		t.Fatal("dialing a denied address succeeded")
+	}
+
+	allowed := DefaultPushURLPolicy()
+	allowed.AllowedSchemes = []string{"http"}
+	allowed.AllowedCIDRs = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+	allowed.VerifyOwnership = true
+	if err := allowed.Accept(context.Background(), receiver.URL); err != nil {
+		t.Fatalf("Accept = %v, want nil", err)
+	}
+
+	if allowed.HTTPClient(0).Transport != allowed.HTTPClient(0).Transport {
+		t.Error("clients of a policy do not share its transport")
+	}
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+// RetentionPolicy bounds what is kept of the tasks of an [AgentExecutor], see [WithRetention].
+//
+// Only tasks in a terminal state are evicted. An evicted task is deleted from the task store
+// with its push notification config, its event log is dropped and it leaves its session,
+// tasks/get then returns [protocol.ErrTaskNotFound]. The tasks known are the ones created
+// since the server started, tasks left in a persistent store by a previous run are kept.
+type RetentionPolicy struct {
+	// TTL is how long a task is kept once it reached a terminal state. Default is forever.
+	TTL time.Duration
+
+	// MaxTasksPerSession caps the tasks of a session, the oldest terminal tasks are evicted
+	// to make room for new ones. Every task counts against the cap, but only terminal tasks
+	// are evicted: a session goes over the cap while its other tasks are submitted, working
+	// or waiting for input. Default is unbounded.
+	MaxTasksPerSession int
+
+	// MaxTasksPerTenant caps the tasks of a tenant, as MaxTasksPerSession.
+	// Default is unbounded.
+	MaxTasksPerTenant int
+
+	// Tenant returns the tenant of a task, e.g. from its metadata.
+	// Default puts every task in the same tenant, making MaxTasksPerTenant a global cap.
+	Tenant func(task *protocol.Task) string
+
+	// MaxHistory caps the messages kept in the history of a task, and its status changes
+	// if recorded, the oldest are dropped. Default is unbounded.
+	MaxHistory int
+
+	// SweepInterval is how often the tasks past their TTL are evicted. Default is TTL/2, at most 1 minute.
+	SweepInterval time.Duration
+}
+
+type (
+	// retention implements RetentionPolicy, a nil retention keeps everything.
+	retention struct {
+		policy RetentionPolicy
+		evict  func(tasks []*retainedTask)
+
+		mu       sync.Mutex
+		tasks    map[string]*retainedTask
+		sweeping bool
+	}
+
+	retainedTask struct {
+		id      string
+		session string
+		tenant  string
+
+		// zero until the task reaches a terminal state.
+		finished time.Time
+	}
+)
+
+func newRetention(policy RetentionPolicy, evict func(tasks []*retainedTask)) *retention {
+	if policy.SweepInterval <= 0 {
+		policy.SweepInterval = max(min(policy.TTL/2, time.Minute), time.Millisecond)
+	}
+
+	return &retention{
+		policy: policy,
+		evict:  evict,
+		tasks:  make(map[string]*retainedTask),
+	}
+}
+
+// track records a new task, and returns the terminal tasks to evict to keep its session
+// and tenant under their caps.
+func (r *retention) track(task *protocol.Task) []*retainedTask {
+	if r == nil {
+		return nil
+	}
+
+	t := &retainedTask{id: task.ID, session: task.SessionID}
+	if r.policy.Tenant != nil {
+		t.tenant = r.policy.Tenant(task)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tasks[t.id] = t
+
+	var victims []*retainedTask
+	if r.policy.MaxTasksPerSession > 0 {
+		victims = append(victims, r.overflow(r.policy.MaxTasksPerSession, func(o *retainedTask) bool {
+			return o.session == t.session
+		})...)
+	}
+
+	if r.policy.MaxTasksPerTenant > 0 {
+		victims = append(victims, r.overflow(r.policy.MaxTasksPerTenant, func(o *retainedTask) bool {
+			return o.tenant == t.tenant
+		})...)
+	}
+
+	return victims
+}
+
+// overflow removes, and returns, the oldest terminal tasks of the group over limit.
+// Every task of the group counts, running tasks are never returned.
+// It must be called with r.mu held.
+func (r *retention) overflow(limit int, group func(t *retainedTask) bool) []*retainedTask {
+	var (
+		count    int
+		finished []*retainedTask
+	)
+
+	for _, t := range r.tasks {
+		if !group(t) {
+			continue
+		}
+
+		count++
+		if !t.finished.IsZero() {
+			finished = append(finished, t)
+		}
+	}
+
+	if count <= limit {
+		return nil
+	}
+
+	slices.SortFunc(finished, func(a, b *retainedTask) int {
+		return a.finished.Compare(b.finished)
+	})
+
+	finished = finished[:min(count-limit, len(finished))]
+	for _, t := range finished {
+		delete(r.tasks, t.id)
+	}
+
+	return finished
+}
+
+// finish records that the task reached a terminal state, starting its TTL.
+func (r *retention) finish(taskID string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tasks[taskID]
+	if !ok || !t.finished.IsZero() {
+		return
+	}
+
+	t.finished = time.Now()
+	if r.policy.TTL > 0 && !r.sweeping {
+		r.sweeping = true
+		go r.sweep()
+	}
+}
+
+// sweep evicts the tasks past their TTL, until no terminal task is left.
+func (r *retention) sweep() {
+	ticker := time.NewTicker(r.policy.SweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		var (
+			victims []*retainedTask
+			pending bool
+			now     = time.Now()
+		)
+
+		r.mu.Lock()
+		for id, t := range r.tasks {
+			switch {
+			case t.finished.IsZero():
+			case now.Sub(t.finished) > r.policy.TTL:
+				victims = append(victims, t)
+				delete(r.tasks, id)
+			default:
+				pending = true
+			}
+		}
+
+		// finish starts a new sweeper once a task reaches a terminal state.
+		r.sweeping = pending
+		r.mu.Unlock()
+
+		r.evict(victims)
+		if !pending {
+			return
+		}
+	}
+}
+
+// trim drops the oldest messages of the history over MaxHistory.
+func (r *retention) trim(history []protocol.Message) []protocol.Message {
+	if r == nil || r.policy.MaxHistory <= 0 || len(history) <= r.policy.MaxHistory {
+		return history
+	}
+
+	return history[len(history)-r.policy.MaxHistory:]
+}
+
+// trimStatus drops the oldest status changes over MaxHistory.
+func (r *retention) trimStatus(history []protocol.TaskStatus) []protocol.TaskStatus {
+	if r == nil || r.policy.MaxHistory <= 0 || len(history) <= r.policy.MaxHistory {
+		return history
+	}
+
+	return history[len(history)-r.policy.MaxHistory:]
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+func TestRetentionSessionCap(t *testing.T) {
+	tests := []struct {
+		name     string
+		finished []string
+		want     []string
+	}{
+		{name: "running tasks are kept over the cap"},
+		{name: "oldest terminal task is evicted", finished: []string{"t2", "t1"}, want: []string{"t2"}},
+		{name: "only as many as over the cap", finished: []string{"t1", "t2", "t3"}, want: []string{"t1"}},
+		{name: "running tasks count against the cap", finished: []string{"t3"}, want: []string{"t3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRetention(RetentionPolicy{MaxTasksPerSession: 3}, func([]*retainedTask) {})
+			for _, id := range []string{"t1", "t2", "t3"} {
+				if victims := r.track(&protocol.Task{ID: id, SessionID: "s1"}); len(victims) != 0 {
+					t.Fatalf("track %s evicted %d tasks under the cap", id, len(victims))
+				}
+			}
+
+			for _, id := range tt.finished {
+				r.finish(id)
+			}
+
+			var got []string
+			for _, v := range r.track(&protocol.Task{ID: "t4", SessionID: "s1"}) {
+				got = append(got, v.id)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("evicted %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetentionSessionCapMixed(t *testing.T) {
+	r := newRetention(RetentionPolicy{MaxTasksPerSession: 2}, func([]*retainedTask) {})
+
+	// t1 working, t2 and t3 terminal: the session is one over the cap once t3 is tracked.
+	var got []string
+	for _, id := range []string{"t1", "t2", "t3", "t4"} {
+		for _, v := range r.track(&protocol.Task{ID: id, SessionID: "s1"}) {
+			got = append(got, v.id)
+		}
+
+		if id != "t1" {
+			r.finish(id)
+		}
+	}
+
+	if want := []string{"t2", "t3"}; !slices.Equal(got, want) {
+		t.Fatalf("evicted %v, want %v", got, want)
+	}
+
+	// the working task is kept, with the last terminal task.
+	var kept []string
+	for id := range r.tasks {
+		kept = append(kept, id)
+	}
+	slices.Sort(kept)
+
+	if want := []string{"t1", "t4"}; !slices.Equal(kept, want) {
+		t.Fatalf("kept %v, want %v", kept, want)
+	}
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+// SendMode is how tasks/send waits for the task, see [WithSendMode].
+type SendMode int
+
+const (
+	// SendBlocking waits for the task to reach a final state, up to the timeout if any,
+	// then returns the task as it is.
+	SendBlocking SendMode = iota
+
+	// SendAsync returns the task as soon as it is submitted, the work goes on in the background.
+	// The client follows the task with tasks/get, tasks/resubscribe or push notifications.
+	//
+	// With a [protocol.IA2AProtocol] handler that has not registered the task yet, the task returned
+	// is a placeholder built by the server: the task as sent, in the submitted state, which the handler
+	// may still reject. Tasks of an [AgentExecutor] are always registered before being returned.
+	SendAsync
+)
+
+// blocking returns whether tasks/send waits for the task, and for how long at most, 0 if unbounded.
+func (s *A2AServer) blocking(params *protocol.TaskSendParams) (bool, time.Duration) {
+	wait, timeout := s.sendMode == SendBlocking, s.sendTimeout
+
+	if v, ok := params.Metadata[protocol.MetadataBlocking].(bool); ok {
+		wait = v
+	}
+
+	if v, ok := params.Metadata[protocol.MetadataBlockingTimeout].(float64); ok && v > 0 {
+		d := time.Duration(v * float64(time.Millisecond))
+		if timeout <= 0 || d < timeout {
+			timeout = d
+		}
+	}
+
+	return wait, timeout
+}
+
+// sendTask handles tasks/send, a retry of the last send of the task returns the task
+// instead of starting the work again, see [WithSendDedup].
+func (s *A2AServer) sendTask(ctx context.Context, params *protocol.TaskSendParams) (ret *protocol.Task, err error) {
+	if err = s.checkContentTypes(ctx, params); err != nil {
+		return nil, err
+	}
+
+	key := dedupKey(params, s.dedupMessages)
+	if s.dedup == nil || key == "" {
+		return s.startTask(ctx, params)
+	}
+
+	e, dup := s.dedup.begin(params.ID, key)
+	if dup {
+		return s.duplicate(ctx, params, e)
+	}
+
+	defer func() {
+		s.dedup.end(params.ID, e, ret, err)
+	}()
+
+	return s.startTask(ctx, params)
+}
+
+// duplicate returns the task of a retried send.
+func (s *A2AServer) duplicate(ctx context.Context, params *protocol.TaskSendParams, e *dedupSend) (*protocol.Task, error) {
+	ret, err := s.handler.GetTask(ctx, &protocol.TaskSendParams{
+		ID:            params.ID,
+		SessionID:     params.SessionID,
+		HistoryLength: params.HistoryLength,
+	})
+	if !protocol.Is(err, protocol.ErrTaskNotFound) {
+		return ret, err
+	}
+
+	// the handler doesn't know the task yet, wait for the original send.
+	select {
+	case <-e.done:
+		return e.task, e.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// startTask sends the task to the handler in the mode chosen, see [WithSendMode].
+//
+// The tasks of a [protocol.IA2AProtocol] not waited for are sent to the handler in the background,
+// detached from the request. The client then gets the task from tasks/get or, if the handler doesn't
+// know it yet, a placeholder: the task as sent, in the submitted state, which the handler has not
+// seen yet and may still reject. Errors of the handler after that are only seen by tasks/get.
+func (s *A2AServer) startTask(ctx context.Context, params *protocol.TaskSendParams) (*protocol.Task, error) {
+	session, err := s.taskSession(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = withSession(ctx, session)
+	wait, timeout := s.blocking(params)
+
+	if m, ok := s.handler.(*taskManager); ok {
+		ret, err := m.send(ctx, params, wait, timeout)
+		if err != nil {
+			return nil, err
+		}
+
+		s.sessions.join(session, params.ID).setArtifacts(ret.ID, ret.Artifacts)
+		return ret, nil
+	}
+
+	// queue synchronously, so the client is the one told the server is busy.
+	ticket, err := s.pool.enqueue(taskPriority(params.Metadata))
+	if err != nil {
+		return nil, err
+	}
+
+	send := func(ctx context.Context) (*protocol.Task, error) {
+		hctx, done := s.inflight.track(ctx, params.ID)
+		defer done()
+
+		hctx, release, err := s.start(hctx, ticket, params)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		ret, err := s.handler.SendTask(hctx, params)
+		if err != nil {
+			return nil, err
+		}
+
+		s.sessions.join(session, params.ID).setArtifacts(ret.ID, ret.Artifacts)
+		return ret, nil
+	}
+
+	if wait && timeout <= 0 {
+		return send(ctx)
+	}
+
+	type result struct {
+		task *protocol.Task
+		err  error
+	}
+
+	ch := make(chan result, 1)
+	go func() {
+		ret, err := send(context.WithoutCancel(ctx))
+		ch <- result{ret, err}
+	}()
+
+	if wait {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case r := <-ch:
+			return r.task, r.err
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	ret, err := s.handler.GetTask(ctx, &protocol.TaskSendParams{
+		ID:            params.ID,
+		SessionID:     params.SessionID,
+		HistoryLength: params.HistoryLength,
+	})
+	if protocol.Is(err, protocol.ErrTaskNotFound) {
+		return &protocol.Task{
+			ID:        params.ID,
+			SessionID: session.ID(),
+			Status:    protocol.TaskStatus{State: protocol.TaskStateSubmitted},
+			History:   []protocol.Message{params.Message},
+			Metadata:  params.Metadata,
+		}, nil
+	}
+
+	return ret, err
+}
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+// sendHandler works on a task until release is closed. Unless register is set,
+// the task is only known to tasks/get once done.
+type sendHandler struct {
+	protocol.IA2AProtocol
+	register bool
+	release  chan struct{}
+
+	mu    sync.Mutex
+	tasks map[string]*protocol.Task
+}
+
+func (h *sendHandler) AgentCard() protocol.AgentCard {
+	return protocol.AgentCard{Name: "test"}
+}
+
+func (h *sendHandler) SendTask(ctx context.Context, params *protocol.TaskSendParams) (*protocol.Task, error) {
+	if h.register {
+		h.save(&protocol.Task{ID: params.ID, Status: protocol.TaskStatus{State: protocol.TaskStateWorking}})
+	}
+
+	<-h.release
+
+	task := &protocol.Task{ID: params.ID, Status: protocol.TaskStatus{State: protocol.TaskStateCompleted}}
+	h.save(task)
+	return task, nil
+}
+
+func (h *sendHandler) GetTask(ctx context.Context, params *protocol.TaskSendParams) (*protocol.Task, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	task, ok := h.tasks[params.ID]
+	if !ok {
+		return nil, protocol.ErrTaskNotFound.New().Args(params.ID)
+	}
+
+	return task, nil
+}
+
+func (h *sendHandler) save(task *protocol.Task) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.tasks == nil {
+		h.tasks = map[string]*protocol.Task{}
+	}
+	h.tasks[task.ID] = task
+}
+
+// sendModeTests are the send modes tested, for both kinds of handler.
+var sendModeTests = []struct {
+	name      string
+	mode      SendMode
+	timeout   time.Duration
+	metadata  map[string]any
+	hold      bool
+	wantState protocol.TaskState
+}{
+	{name: "async", mode: SendAsync, hold: true, wantState: protocol.TaskStateSubmitted},
+	{name: "blocking", mode: SendBlocking, wantState: protocol.TaskStateCompleted},
+	{
+		name: "client chooses async", mode: SendBlocking, hold: true,
+		metadata:  map[string]any{protocol.MetadataBlocking: false},
+		wantState: protocol.TaskStateSubmitted,
+	},
+	{
+		name: "client chooses blocking", mode: SendAsync,
+		metadata:  map[string]any{protocol.MetadataBlocking: true},
+		wantState: protocol.TaskStateCompleted,
+	},
+	{
+		name: "client timeout", mode: SendBlocking, hold: true,
+		metadata:  map[string]any{protocol.MetadataBlockingTimeout: float64(50)},
+		wantState: protocol.TaskStateWorking,
+	},
+	{
+		name: "client timeout capped by the server", mode: SendBlocking, timeout: 50 * time.Millisecond, hold: true,
+		metadata:  map[string]any{protocol.MetadataBlockingTimeout: float64(time.Minute / time.Millisecond)},
+		wantState: protocol.TaskStateWorking,
+	},
+}
+
+func TestSendModeExecutor(t *testing.T) {
+	for _, tt := range sendModeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+			if !tt.hold {
+				close(release)
+			}
+
+			// a single worker, held by the first task: the async sends are still queued when returned.
+			s := NewA2AServerWithExecutor(protocol.AgentCard{Name: "test"}, AgentExecutorFunc(
+				func(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+					if err := updater.Working(ctx); err != nil {
+						return err
+					}
+
+					select {
+					case <-release:
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+
+					return updater.Complete(ctx)
+				}),
+				WithSendMode(tt.mode, tt.timeout),
+				WithWorkerPool(WorkerPoolPolicy{Workers: 1, QueueDepth: 1}),
+			)
+			defer func() {
+				if tt.hold {
+					close(release)
+				}
+			}()
+
+			ctx := context.Background()
+			if tt.wantState == protocol.TaskStateSubmitted {
+				busy := sendParams("busy", "hello")
+				busy.Metadata = map[string]any{protocol.MetadataBlocking: false}
+				if _, err := s.sendTask(ctx, busy); err != nil {
+					t.Fatalf("sendTask = %v", err)
+				}
+			}
+
+			params := sendParams("t1", "hello")
+			params.Metadata = tt.metadata
+
+			start := time.Now()
+			task, err := s.sendTask(ctx, params)
+			if err != nil {
+				t.Fatalf("sendTask = %v", err)
+			}
+
+			if task.Status.State != tt.wantState {
+				t.Fatalf("state = %s, want %s", task.Status.State, tt.wantState)
+			}
+
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("sendTask returned after %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestSendModeHandler(t *testing.T) {
+	for _, tt := range sendModeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &sendHandler{
+				register: tt.wantState == protocol.TaskStateWorking,
+				release:  make(chan struct{}),
+			}
+			if !tt.hold {
+				close(h.release)
+			}
+
+			s := NewA2AServer(h, WithSendMode(tt.mode, tt.timeout))
+			defer func() {
+				if tt.hold {
+					close(h.release)
+				}
+			}()
+
+			params := sendParams("t1", "hello")
+			params.Metadata = tt.metadata
+
+			start := time.Now()
+			task, err := s.sendTask(context.Background(), params)
+			if err != nil {
+				t.Fatalf("sendTask = %v", err)
+			}
+
+			if task.Status.State != tt.wantState {
+				t.Fatalf("state = %s, want %s", task.Status.State, tt.wantState)
+			}
+
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("sendTask returned after %v", elapsed)
+			}
+
+			// the handler has not seen the task yet: the placeholder is the task as sent.
+			if tt.wantState == protocol.TaskStateSubmitted {
+				if task.ID != params.ID || len(task.History) != 1 || task.History[0].Parts[0].Text != "hello" {
+					t.Fatalf("placeholder = %+v, want the task as sent", task)
+				}
+			}
+		})
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/zhengrenjie/go-a2a/protocol"
 )
@@ -12,12 +15,74 @@ type IA2AServerHost interface {
 	Host(server *A2AServer) error
 }
 
-func NewA2AServer(p protocol.IA2AProtocol) *A2AServer {
-	return &A2AServer{handler: p}
+func NewA2AServer(p protocol.IA2AProtocol, opts ...Option) *A2AServer {
+	s := &A2AServer{
+		handler:    p,
+		pushPolicy: DefaultPushURLPolicy(),
+		events:     newEventLog(100, 10*time.Minute),
+		sessions:   newSessionManager(30 * time.Minute),
+		inflight:   newCancelRegistry(),
+		dedup:      newSendDedup(10 * time.Minute),
+
+		backpressure: BackpressurePolicy{Mode: BackpressureDropStatus, BlockTimeout: 30 * time.Second},
+		inputTimeout: time.Hour,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// NewA2AServerWithExecutor returns a server hosting the executor, see [AgentExecutor].
+// Tasks are kept in the task store set by [WithTaskStore], in memory by default.
+func NewA2AServerWithExecutor(card protocol.AgentCard, executor AgentExecutor, opts ...Option) *A2AServer {
+	s := NewA2AServer(nil, opts...)
+	s.handler = newTaskManager(card, executor, s)
+	return s
 }
 
 type A2AServer struct {
-	handler protocol.IA2AProtocol
+	handler       protocol.IA2AProtocol
+	pushPolicy    *PushURLPolicy
+	events        *eventLog
+	backpressure  BackpressurePolicy
+	sessions      *sessionManager
+	sessionTasks  bool
+	listTasks     bool
+	taskScope     TaskScope
+	inflight      *cancelRegistry
+	pool          *workerPool
+	sendMode      SendMode
+	sendTimeout   time.Duration
+	dedup         *sendDedup
+	dedupMessages bool
+
+	// used by the executor only.
+	store        ITaskStore
+	pushSigner   *pushSigner
+	retention    *RetentionPolicy
+	inputTimeout time.Duration
+
+	// the agent card as served, see [WithCardSigning] and [WithExtendedCard].
+	card         atomic.Pointer[protocol.AgentCard]
+	cardSigner   *cardSigner
+	extendedCard ExtendedCardFunc
+	cardMu       sync.Mutex
+	cardDoc      *cardDocument
+
+	// custom methods, see [HandleMethod].
+	methodsMu sync.RWMutex
+	methods   map[protocol.A2AMethod]*customMethod
+}
+
+// StreamEvent is a single message of a task stream.
+type StreamEvent struct {
+	// ID of the event in the event log of the task, empty if not logged.
+	ID string
+
+	Response *protocol.JsonRpcResponse
 }
 
 type StreamingType interface {
@@ -28,17 +93,54 @@ type Streaming[T StreamingType] struct {
 	Data chan T
 }
 
+// AgentCard returns the card set by [A2AServer.SetAgentCard] if any, the one of the handler otherwise.
 func (s *A2AServer) AgentCard() protocol.AgentCard {
+	if card := s.card.Load(); card != nil {
+		return *card
+	}
+
 	return s.handler.AgentCard()
 }
 
+// SetAgentCard validates the card, then swaps it in for the card of the handler, e.g. on a
+// configuration reload. Requests started before keep the former card.
+// Settings read from the card once, such as stateTransitionHistory for an [AgentExecutor], are kept.
+func (s *A2AServer) SetAgentCard(card protocol.AgentCard) error {
+	if err := card.Validate(); err != nil {
+		return err
+	}
+
+	s.card.Store(&card)
+	return nil
+}
+
+// CheckMethod returns the error answering the method if the public agent card rules it out,
+// see [protocol.AgentCard.CheckMethod]. HandleMessage and HandleStreaming check it already,
+// against the card of the caller, see [A2AServer.AgentCardFor].
+func (s *A2AServer) CheckMethod(method protocol.A2AMethod) error {
+	card := s.AgentCard()
+	return card.CheckMethod(method)
+}
+
+// checkMethod is CheckMethod against the card of the caller of ctx, see [A2AServer.AgentCardFor].
+func (s *A2AServer) checkMethod(ctx context.Context, method protocol.A2AMethod) error {
+	card := s.AgentCardFor(ctx)
+	return card.CheckMethod(method)
+}
+
 // HandleMessage handles the following no-streaming methods:
 //   - tasks/send
 //   - tasks/get
 //   - tasks/cancel
 //   - tasks/pushNotification/set
 //   - tasks/pushNotification/get
+//   - sessions/tasks, if enabled by [WithSessionTasksMethod]
+//   - tasks/list, if enabled by [WithListTasksMethod]
+//   - the custom methods registered by [HandleMethod]
 func (s *A2AServer) HandleMessage(ctx context.Context, raw *JsonRpcRaw) *protocol.JsonRpcResponse {
+	if err := s.checkMethod(ctx, raw.Method); err != nil {
+		return s.handleError(raw.ID, err)
+	}
 
 	var params any
 	switch raw.Method {
@@ -49,7 +151,12 @@ func (s *A2AServer) HandleMessage(ctx context.Context, raw *JsonRpcRaw) *protoco
 			return protocol.ErrJsonRpcParamsParse.New().ToJsonRpc(raw.ID)
 		}
 
-		ret, err := s.handler.SendTask(ctx, params.(*protocol.TaskSendParams))
+		err = s.acceptPushNotification(ctx, params.(*protocol.TaskSendParams).PushNotification)
+		if err != nil {
+			return s.handleError(raw.ID, err)
+		}
+
+		ret, err := s.sendTask(ctx, params.(*protocol.TaskSendParams))
 		if err != nil {
 			return s.handleError(raw.ID, err)
 		}
@@ -80,6 +187,16 @@ func (s *A2AServer) HandleMessage(ctx context.Context, raw *JsonRpcRaw) *protoco
 			return s.handleError(raw.ID, err)
 		}
 
+		if ret == nil {
+			return s.handleError(raw.ID, protocol.ErrInternalError.New().Args("tasks/cancel returned no task"))
+		}
+
+		// the handler may not have canceled the task, e.g. it completed meanwhile.
+		if ret.Status.State != protocol.TaskStateCanceled {
+			return s.handleError(raw.ID, protocol.ErrTaskCannotCancel.New().Args(ret.ID, ret.Status.State))
+		}
+
+		s.cancel(ret)
 		return s.response(raw.ID, ret)
 	case protocol.MethodSetTaskPushNotifications:
 		params = new(protocol.TaskPushNotificationConfig)
@@ -88,6 +205,11 @@ func (s *A2AServer) HandleMessage(ctx context.Context, raw *JsonRpcRaw) *protoco
 			return protocol.ErrJsonRpcParamsParse.New().ToJsonRpc(raw.ID)
 		}
 
+		err = s.acceptPushNotification(ctx, &params.(*protocol.TaskPushNotificationConfig).PushNotificationConfig)
+		if err != nil {
+			return s.handleError(raw.ID, err)
+		}
+
 		ret, err := s.handler.SetTaskPushNotifications(ctx, params.(*protocol.TaskPushNotificationConfig))
 		if err != nil {
 			return s.handleError(raw.ID, err)
@@ -106,16 +228,395 @@ func (s *A2AServer) HandleMessage(ctx context.Context, raw *JsonRpcRaw) *protoco
 			return s.handleError(raw.ID, err)
 		}
 
+		return s.response(raw.ID, ret)
+	case protocol.MethodListSessionTasks:
+		if !s.sessionTasks {
+			break
+		}
+
+		params = new(protocol.SessionTasksParams)
+		err := json.Unmarshal(raw.Params, params)
+		if err != nil {
+			return protocol.ErrJsonRpcParamsParse.New().ToJsonRpc(raw.ID)
+		}
+
+		ret, err := s.listSessionTasks(ctx, params.(*protocol.SessionTasksParams))
+		if err != nil {
+			return s.handleError(raw.ID, err)
+		}
+
+		return s.response(raw.ID, ret)
+	case protocol.MethodListTasks:
+		lister, ok := s.handler.(protocol.ITaskLister)
+		if !s.listTasks || !ok {
+			break
+		}
+
+		params = new(protocol.ListTasksParams)
+		err := json.Unmarshal(raw.Params, params)
+		if err != nil {
+			return protocol.ErrJsonRpcParamsParse.New().ToJsonRpc(raw.ID)
+		}
+
+		ret, err := lister.ListTasks(ctx, params.(*protocol.ListTasksParams))
+		if err != nil {
+			return s.handleError(raw.ID, err)
+		}
+
 		return s.response(raw.ID, ret)
 	}
 
+	if m := s.method(raw.Method); m != nil && m.unary != nil {
+		return s.handleCustom(ctx, m, raw)
+	}
+
 	return protocol.ErrMethodNotFound.New().
 		Args(raw.Method).
 		ToJsonRpc(raw.ID)
 }
 
-func (s *A2AServer) HandleStreaming(ctx context.Context, raw *JsonRpcRaw, streaming <-chan *protocol.JsonRpcResponse) {
+// HandleStreaming handles the following streaming methods:
+//   - tasks/sendSubscribe
+//   - tasks/resubscribe
+//   - the custom methods registered by [HandleStreamMethod]
+//
+// Events are sent to streaming, which is closed when the stream ends.
+// Every event is recorded in the event log of the task. Resubscribing with the id of the last
+// event received, either by lastEventID or by [protocol.MetadataLastEventID] in params metadata,
+// replays the missed events before the live ones. See [WithEventLog].
+func (s *A2AServer) HandleStreaming(ctx context.Context, raw *JsonRpcRaw, lastEventID string, streaming chan<- StreamEvent) {
+	defer close(streaming)
+
+	send := func(e StreamEvent) bool {
+		select {
+		case streaming <- e:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	if err := s.checkMethod(ctx, raw.Method); err != nil {
+		send(StreamEvent{Response: s.handleError(raw.ID, err)})
+		return
+	}
+
+	if m := s.method(raw.Method); m != nil && m.stream != nil {
+		s.streamCustom(ctx, m, raw, send)
+		return
+	}
+
+	params := new(protocol.TaskSendParams)
+	err := json.Unmarshal(raw.Params, params)
+	if err != nil {
+		send(StreamEvent{Response: protocol.ErrJsonRpcParamsParse.New().ToJsonRpc(raw.ID)})
+		return
+	}
+
+	var (
+		replay    []loggedEvent
+		sub       *subscription
+		producing bool
+		events    chan any
+		session   *Session
+	)
+
+	// ctx of the handler, canceled by tasks/cancel.
+	hctx, done := s.inflight.track(ctx, params.ID)
+
+	switch raw.Method {
+	case protocol.MethodSubscribeTask:
+		err = s.checkContentTypes(ctx, params)
+		if err == nil {
+			err = s.acceptPushNotification(ctx, params.PushNotification)
+		}
+
+		if err != nil {
+			done()
+			send(StreamEvent{Response: s.handleError(raw.ID, err)})
+			return
+		}
+
+		session, err = s.taskSession(ctx, params)
+		if err != nil {
+			done()
+			send(StreamEvent{Response: s.handleError(raw.ID, err)})
+			return
+		}
+
+		var release func()
+		hctx, release, err = s.admit(hctx, params)
+		if err != nil {
+			done()
+			send(StreamEvent{Response: s.handleError(raw.ID, err)})
+			return
+		}
+
+		untrack := done
+		done = func() {
+			release()
+			untrack()
+		}
+
+		events, err = s.handler.SubscribeTask(withSession(hctx, session), params)
+		if err != nil {
+			done()
+			send(StreamEvent{Response: s.handleError(raw.ID, err)})
+			return
+		}
+
+		session = s.sessions.join(session, params.ID)
+
+		// subscribe before producing, so no event is missed.
+		_, sub, _ = s.events.subscribe(params.ID, "", true, s.backpressure)
+	case protocol.MethodResubscribeTask:
+		if lastEventID == "" {
+			lastEventID, _ = params.Metadata[protocol.MetadataLastEventID].(string)
+		}
+
+		replay, sub, producing = s.events.subscribe(params.ID, lastEventID, false, s.backpressure)
+		if params.SessionID != nil {
+			session, _ = s.sessions.get(ctx, *params.SessionID)
+		}
+
+		// nobody is producing live events of the task, ask the handler for them.
+		if sub != nil && !producing {
+			if session != nil {
+				hctx = withSession(hctx, session)
+			}
+
+			events, err = s.handler.ResubscribeTask(hctx, params)
+			if err != nil {
+				s.events.unsubscribe(params.ID, sub)
+				sub = nil
+			}
+		}
+	default:
+		done()
+		send(StreamEvent{Response: protocol.ErrMethodNotFound.New().Args(raw.Method).ToJsonRpc(raw.ID)})
+		return
+	}
+
+	if sub != nil {
+		defer s.events.unsubscribe(params.ID, sub)
+	}
+
+	if events != nil {
+		produced := s.events.produce(params.ID)
+		go s.produce(hctx, params.ID, session, events, func() {
+			produced()
+			done()
+		})
+	} else {
+		done()
+	}
+
+	for _, e := range replay {
+		if !send(s.streamEvent(raw.ID, e)) {
+			return
+		}
+	}
+
+	if err != nil {
+		send(StreamEvent{Response: s.handleError(raw.ID, err)})
+		return
+	}
+
+	if sub == nil {
+		return
+	}
+
+	for {
+		e, ok := sub.q.next(ctx)
+		if !ok {
+			return
+		}
+
+		if !send(s.streamEvent(raw.ID, e)) {
+			return
+		}
+	}
+}
+
+// produce records the events produced by the handler in the event log of the task,
+// and the artifacts in the session of the task if any,
+// until the handler closes events or ctx, the context the handler was given, is done.
+func (s *A2AServer) produce(ctx context.Context, taskID string, session *Session, events chan any, done func()) {
+	defer done()
+
+	record := func(event any) {
+		if e, ok := event.(*protocol.TaskStatusUpdateEvent); ok && e.Status.Timestamp == nil {
+			stamped := *e
+			stamped.Status.Timestamp = timestamp(time.Now())
+			event = &stamped
+		}
+
+		s.events.append(taskID, event)
+
+		if e, ok := event.(*protocol.TaskArtifactUpdateEvent); ok && session != nil {
+			session.addArtifact(taskID, e.Artifact)
+		}
+	}
+
+	for {
+		select {
+		case event, more := <-events:
+			if !more {
+				return
+			}
+
+			record(event)
+		case <-ctx.Done():
+			// keep what the handler produced before noticing, but never wait for it.
+			for {
+				select {
+				case event, more := <-events:
+					if !more {
+						return
+					}
+
+					record(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// admit waits for a worker of the pool for the handler call starting the task, see [WithWorkerPool].
+// The returned ctx is bounded by the timeout of the task, the returned function gives the worker back.
+//
+// The executions of an [AgentExecutor] are queued by the server itself, in the submitted state,
+// so they are admitted as is.
+func (s *A2AServer) admit(ctx context.Context, params *protocol.TaskSendParams) (context.Context, func(), error) {
+	if _, ok := s.handler.(*taskManager); ok || s.pool == nil {
+		return ctx, func() {}, nil
+	}
+
+	ticket, err := s.pool.enqueue(taskPriority(params.Metadata))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.start(ctx, ticket, params)
+}
+
+// start waits for the ticket to hold a worker, then bounds ctx by the timeout of the task.
+// The returned function gives the worker back.
+func (s *A2AServer) start(ctx context.Context, ticket *poolTicket, params *protocol.TaskSendParams) (context.Context, func(), error) {
+	if err := ticket.wait(ctx); err != nil {
+		ticket.release()
+		return nil, nil, err
+	}
+
+	cancel := context.CancelFunc(func() {})
+	if timeout := s.pool.timeout(taskSkill(params.Metadata)); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	return ctx, func() {
+		cancel()
+		ticket.release()
+	}, nil
+}
+
+// cancel propagates the cancellation of the task: the final status is sent to the streams
+// of the task, then the handler calls working on it are canceled.
+func (s *A2AServer) cancel(task *protocol.Task) {
+	s.events.append(task.ID, &protocol.TaskStatusUpdateEvent{
+		ID:     task.ID,
+		Status: task.Status,
+		Final:  true,
+	})
+
+	s.inflight.cancel(task.ID)
+}
+
+// forget drops what the server keeps of an evicted task.
+func (s *A2AServer) forget(taskID, sessionID string) {
+	s.events.remove(taskID)
+	s.dedup.forget(taskID)
+
+	s.sessions.leave(taskID, sessionID)
+}
+
+// taskSession returns the session of the task sent with params, and sets its id to params:
+// the session given by params, or else the session the task already belongs to, or else a new one.
+// The task is not recorded in the session, see [sessionManager.join].
+func (s *A2AServer) taskSession(ctx context.Context, params *protocol.TaskSendParams) (*Session, error) {
+	if params.SessionID == nil || *params.SessionID == "" {
+		id, ok := s.sessions.sessionOf(params.ID)
+		if !ok {
+			// the session may have been evicted while the handler still has the task.
+			task, err := s.handler.GetTask(ctx, &protocol.TaskSendParams{ID: params.ID, HistoryLength: new(int)})
+			if err == nil && task.SessionID != "" {
+				id = task.SessionID
+			} else {
+				id = newID()
+			}
+		}
+
+		params.SessionID = &id
+	}
+
+	return s.sessions.open(ctx, *params.SessionID)
+}
+
+// listSessionTasks returns the tasks of the session, as returned by tasks/get.
+func (s *A2AServer) listSessionTasks(ctx context.Context, params *protocol.SessionTasksParams) ([]*protocol.Task, error) {
+	session, ok := s.sessions.get(ctx, params.SessionID)
+	if !ok {
+		return nil, protocol.ErrSessionNotFound.New().Args(params.SessionID)
+	}
+
+	ctx = withSession(ctx, session)
+	ret := make([]*protocol.Task, 0)
+	for _, id := range session.Tasks() {
+		task, err := s.handler.GetTask(ctx, &protocol.TaskSendParams{
+			ID:            id,
+			SessionID:     &params.SessionID,
+			HistoryLength: params.HistoryLength,
+		})
+
+		// the task may be gone, e.g. evicted by the handler.
+		if protocol.Is(err, protocol.ErrTaskNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, task)
+	}
+
+	return ret, nil
+}
 
+func (s *A2AServer) streamEvent(id uint64, e loggedEvent) StreamEvent {
+	return StreamEvent{
+		ID:       e.id,
+		Response: s.response(id, e.event),
+	}
+}
+
+// acceptPushNotification validates the push notification config against the agent card
+// and the push url policy.
+func (s *A2AServer) acceptPushNotification(ctx context.Context, config *protocol.PushNotificationConfig) error {
+	if config == nil {
+		return nil
+	}
+
+	if err := s.checkMethod(ctx, protocol.MethodSetTaskPushNotifications); err != nil {
+		return err
+	}
+
+	if s.pushPolicy == nil {
+		return nil
+	}
+
+	return s.pushPolicy.Accept(ctx, config.Url)
 }
 
 func (s *A2AServer) response(id uint64, ret any) *protocol.JsonRpcResponse {
@@ -132,6 +633,11 @@ func (s *A2AServer) handleError(id uint64, err error) *protocol.JsonRpcResponse
 		return ret.ToJsonRpc(id)
 	}
 
+	var params errParams
+	if errors.As(err, &params) {
+		return protocol.ErrJsonRpcParamsParse.New().Data(params.Error()).ToJsonRpc(id)
+	}
+
 	return protocol.ErrInternalError.New().
 		Args(err.Error()).
 		ToJsonRpc(id)
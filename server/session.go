@@ -0,0 +1,272 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+type (
+	// Session groups the tasks sharing a [protocol.TaskSendParams.SessionID].
+	// Handlers get the session of the task they are working on with [SessionFromContext],
+	// to use what the previous tasks of the session produced.
+	//
+	// A session belongs to the principal who created it, see [PrincipalFromContext],
+	// other callers don't find it.
+	Session struct {
+		id    string
+		owner string
+
+		mu         sync.RWMutex
+		tasks      []string
+		artifacts  map[string][]protocol.Artifact
+		summary    string
+		values     map[string]any
+		lastActive time.Time
+	}
+
+	// sessionManager keeps the sessions, evicting the idle ones.
+	sessionManager struct {
+		mu          sync.Mutex
+		idleTimeout time.Duration
+		lastSweep   time.Time
+		sessions    map[string]*Session
+
+		// the session of each task recorded.
+		tasks map[string]string
+	}
+
+	sessionKey struct{}
+)
+
+// SessionFromContext returns the session of the task being handled.
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+	s, ok := ctx.Value(sessionKey{}).(*Session)
+	return s, ok
+}
+
+func withSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// ID returns the session id.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Tasks returns the ids of the tasks of the session, in the order they were created.
+func (s *Session) Tasks() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.tasks)
+}
+
+// Artifacts returns the artifacts produced by the tasks of the session, in task order.
+func (s *Session) Artifacts() []protocol.Artifact {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ret []protocol.Artifact
+	for _, id := range s.tasks {
+		ret = append(ret, s.artifacts[id]...)
+	}
+
+	return ret
+}
+
+// Summary returns the summary of the session, see [Session.SetSummary].
+func (s *Session) Summary() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.summary
+}
+
+// SetSummary sets a summary of the session so far, e.g. of the conversation, for the next tasks.
+func (s *Session) SetSummary(summary string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.summary = summary
+}
+
+// Value returns the value stored for key.
+func (s *Session) Value(key string) (any, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// SetValue stores any value scoped to the session.
+func (s *Session) SetValue(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values[key] = value
+}
+
+// addTask records the task, if not already recorded.
+func (s *Session) addTask(taskID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastActive = time.Now()
+	if !slices.Contains(s.tasks, taskID) {
+		s.tasks = append(s.tasks, taskID)
+	}
+}
+
+// removeTask forgets the task, e.g. once it is evicted.
+func (s *Session) removeTask(taskID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tasks = slices.DeleteFunc(s.tasks, func(id string) bool { return id == taskID })
+	delete(s.artifacts, taskID)
+}
+
+// setArtifacts replaces the artifacts recorded for the task.
+func (s *Session) setArtifacts(taskID string, artifacts []protocol.Artifact) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastActive = time.Now()
+	s.artifacts[taskID] = slices.Clone(artifacts)
+}
+
+// addArtifact records a streamed artifact of the task, appending the chunk to its artifact if needed.
+func (s *Session) addArtifact(taskID string, artifact protocol.Artifact) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastActive = time.Now()
+	artifacts := s.artifacts[taskID]
+	if artifact.Append != nil && *artifact.Append {
+		for i := range artifacts {
+			if artifacts[i].Index == artifact.Index {
+				artifacts[i].Parts = append(slices.Clone(artifacts[i].Parts), artifact.Parts...)
+				artifacts[i].LastChunk = artifact.LastChunk
+				return
+			}
+		}
+	}
+
+	s.artifacts[taskID] = append(artifacts, artifact)
+}
+
+func newSessionManager(idleTimeout time.Duration) *sessionManager {
+	return &sessionManager{
+		idleTimeout: idleTimeout,
+		lastSweep:   time.Now(),
+		sessions:    make(map[string]*Session),
+		tasks:       make(map[string]string),
+	}
+}
+
+// open returns the session for the caller of ctx, a new one if it doesn't exist.
+// A new session is only kept once a task joins it, see [sessionManager.join].
+func (m *sessionManager) open(ctx context.Context, id string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep()
+
+	if s, ok := m.sessions[id]; ok {
+		if s.owner != subject(ctx) {
+			return nil, protocol.ErrSessionNotFound.New().Args(id)
+		}
+
+		return s, nil
+	}
+
+	return &Session{
+		id:         id,
+		owner:      subject(ctx),
+		artifacts:  make(map[string][]protocol.Artifact),
+		values:     make(map[string]any),
+		lastActive: time.Now(),
+	}, nil
+}
+
+// join records the task in the session opened by [sessionManager.open], keeping the session.
+// It returns the session kept, which is the one opened unless created meanwhile by another task.
+func (m *sessionManager) join(s *Session, taskID string) *Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if kept, ok := m.sessions[s.id]; ok {
+		s = kept
+	} else {
+		m.sessions[s.id] = s
+	}
+
+	s.addTask(taskID)
+	m.tasks[taskID] = s.id
+	return s
+}
+
+// leave forgets the task, e.g. once it is evicted.
+func (m *sessionManager) leave(taskID, sessionID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.tasks[taskID] == sessionID {
+		delete(m.tasks, taskID)
+	}
+
+	if s, ok := m.sessions[sessionID]; ok {
+		s.removeTask(taskID)
+	}
+}
+
+// sessionOf returns the id of the session the task joined, if it is still kept.
+func (m *sessionManager) sessionOf(taskID string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep()
+	id, ok := m.tasks[taskID]
+	return id, ok
+}
+
+// get returns the session, if it exists and belongs to the caller of ctx.
+func (m *sessionManager) get(ctx context.Context, id string) (*Session, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep()
+	s, ok := m.sessions[id]
+	if !ok || s.owner != subject(ctx) {
+		return nil, false
+	}
+
+	return s, true
+}
+
+// sweep evicts the idle sessions, must be called with m.mu held.
+func (m *sessionManager) sweep() {
+	if m.idleTimeout <= 0 || time.Since(m.lastSweep) < m.idleTimeout/2 {
+		return
+	}
+
+	now := time.Now()
+	m.lastSweep = now
+	for id, s := range m.sessions {
+		s.mu.RLock()
+		idle := now.Sub(s.lastActive) > m.idleTimeout
+		s.mu.RUnlock()
+
+		if idle {
+			delete(m.sessions, id)
+			for _, taskID := range s.Tasks() {
+				delete(m.tasks, taskID)
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+func completeTask(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+	return updater.Complete(ctx)
+}
+
+func sessionTasks(s *A2AServer, ctx context.Context, sessionID string) ([]string, error) {
+	tasks, err := s.listSessionTasks(ctx, &protocol.SessionTasksParams{SessionID: sessionID})
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+
+	return ids, nil
+}
+
+func TestSessionJoin(t *testing.T) {
+	alice := ContextWithPrincipal(context.Background(), &Principal{Subject: "alice"})
+	bob := ContextWithPrincipal(context.Background(), &Principal{Subject: "bob"})
+
+	s := NewA2AServerWithExecutor(protocol.AgentCard{Name: "test"}, AgentExecutorFunc(completeTask))
+
+	params := sendParams("t1", "hello")
+	params.SessionID = ptr("s1")
+	if _, err := s.sendTask(alice, params); err != nil {
+		t.Fatalf("sendTask = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		taskID    string
+		sessionID string
+		wantErr   protocol.ErrorType
+		session   string
+		want      []string
+	}{
+		{
+			name:   "new task without session id gets a new session",
+			ctx:    alice,
+			taskID: "t2",
+		},
+		{
+			name:      "new task joins the session",
+			ctx:       alice,
+			taskID:    "t3",
+			sessionID: "s1",
+			session:   "s1",
+			want:      []string{"t1", "t3"},
+		},
+		{
+			name:      "rejected send doesn't create the session",
+			ctx:       alice,
+			taskID:    "t1",
+			sessionID: "s2",
+			wantErr:   protocol.ErrTaskTerminated,
+			session:   "s2",
+		},
+		{
+			name:      "session of another principal is not found",
+			ctx:       bob,
+			taskID:    "t4",
+			sessionID: "s1",
+			wantErr:   protocol.ErrSessionNotFound,
+			session:   "s1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := sendParams(tt.taskID, tt.name)
+			if tt.sessionID != "" {
+				params.SessionID = ptr(tt.sessionID)
+			}
+
+			task, err := s.sendTask(tt.ctx, params)
+			if tt.wantErr != (protocol.ErrorType{}) {
+				if !protocol.Is(err, tt.wantErr) {
+					t.Fatalf("sendTask = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil || task.SessionID == "" {
+				t.Fatalf("sendTask = %v, %v, want a task with a session", task, err)
+			}
+
+			if tt.session == "" {
+				return
+			}
+
+			got, err := sessionTasks(s, tt.ctx, tt.session)
+			if tt.want == nil {
+				if !protocol.Is(err, protocol.ErrSessionNotFound) {
+					t.Fatalf("session tasks = %v, %v, want ErrSessionNotFound", got, err)
+				}
+
+				return
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("session tasks = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionFollowUp(t *testing.T) {
+	s := NewA2AServerWithExecutor(protocol.AgentCard{Name: "test"}, AgentExecutorFunc(
+		func(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+			if len(task.History) == 1 {
+				return updater.RequireInput(ctx)
+			}
+
+			return updater.Complete(ctx)
+		}))
+
+	ctx := context.Background()
+	first, err := s.sendTask(ctx, sendParams("t1", "hello"))
+	if err != nil || first.Status.State != protocol.TaskStateInputRequired {
+		t.Fatalf("sendTask = %v, %v, want input-required", first, err)
+	}
+
+	// the follow-up without session id stays in the session of the task.
+	second, err := s.sendTask(ctx, sendParams("t1", "more"))
+	if err != nil || second.SessionID != first.SessionID {
+		t.Fatalf("follow-up = %v, %v, want session %s", second, err, first.SessionID)
+	}
+
+	s.sessions.mu.Lock()
+	sessions := len(s.sessions.sessions)
+	s.sessions.mu.Unlock()
+
+	if sessions != 1 {
+		t.Fatalf("%d sessions, want 1", sessions)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
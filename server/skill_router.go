@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+type (
+	// SkillClassifier picks the skill of the task among the skills of the agent card,
+	// e.g. by asking a model. ok is false if none fits.
+	SkillClassifier func(ctx context.Context, task *protocol.Task, skills []protocol.Skill) (skillID string, ok bool)
+
+	// SkillRouter is an [AgentExecutor] dispatching each task to the executor of its skill.
+	// The skill of a task is, in order:
+	//   - the one requested by [protocol.MetadataSkillID], the task goes to the fallback if it has no executor.
+	//   - the first skill, in card order, declaring input modes that accept every part of the last message.
+	//   - the one picked by the classifier, see [WithSkillClassifier].
+	//
+	// The skills are the ones of the card of the caller, see [A2AServer.AgentCardFor], which follows
+	// [A2AServer.SetAgentCard]. The card given to [NewSkillRouter] is only used outside of a server.
+	// A task requesting a skill not on the card fails with [protocol.ErrSkillNotFound]. Tasks without
+	// a skill having an executor go to the fallback executor, see [WithFallbackExecutor], or fail.
+	SkillRouter struct {
+		card       protocol.AgentCard
+		classifier SkillClassifier
+		fallback   AgentExecutor
+
+		mu        sync.RWMutex
+		executors map[string]AgentExecutor
+	}
+
+	// SkillRouterOption configures a [SkillRouter].
+	SkillRouterOption func(*SkillRouter)
+
+	skillKey struct{}
+)
+
+// WithSkillClassifier sets the classifier picking the skill of the tasks routed neither
+// by skill id nor by input modes.
+func WithSkillClassifier(classifier SkillClassifier) SkillRouterOption {
+	return func(r *SkillRouter) {
+		r.classifier = classifier
+	}
+}
+
+// WithFallbackExecutor sets the executor of the tasks no skill executor is found for.
+func WithFallbackExecutor(executor AgentExecutor) SkillRouterOption {
+	return func(r *SkillRouter) {
+		r.fallback = executor
+	}
+}
+
+// NewSkillRouter returns a router of the skills of the card, add their executors with [SkillRouter.Handle].
+// Hosted by a server, tasks are routed against the card of their caller instead, see [SkillRouter].
+func NewSkillRouter(card protocol.AgentCard, opts ...SkillRouterOption) *SkillRouter {
+	r := &SkillRouter{
+		card:      card,
+		executors: make(map[string]AgentExecutor),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// SkillFromContext returns the skill the task being executed was routed to by a [SkillRouter].
+func SkillFromContext(ctx context.Context) (*protocol.Skill, bool) {
+	s, ok := ctx.Value(skillKey{}).(*protocol.Skill)
+	return s, ok
+}
+
+// Handle sets the executor of the skill.
+func (r *SkillRouter) Handle(skillID string, executor AgentExecutor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.executors[skillID] = executor
+}
+
+// Execute implements AgentExecutor.
+func (r *SkillRouter) Execute(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+	skill, executor, err := r.route(ctx, task)
+	if err != nil {
+		return err
+	}
+
+	if executor == nil {
+		return fmt.Errorf("no executor for the skill of task [%s]", task.ID)
+	}
+
+	if skill != nil {
+		ctx = context.WithValue(ctx, skillKey{}, skill)
+	}
+
+	return executor.Execute(ctx, task, updater)
+}
+
+// route returns the skill of the task and its executor, the fallback if none.
+func (r *SkillRouter) route(ctx context.Context, task *protocol.Task) (*protocol.Skill, AgentExecutor, error) {
+	card := r.cardFor(ctx)
+
+	// a requested skill is never swapped for another one.
+	if id := taskSkill(task.Metadata); id != "" {
+		skill, ok := card.Skill(id)
+		if !ok {
+			return nil, nil, protocol.ErrSkillNotFound.New().Args(id)
+		}
+
+		if executor := r.executor(id); executor != nil {
+			return skill, executor, nil
+		}
+
+		return nil, r.fallback, nil
+	}
+
+	if len(task.History) > 0 {
+		parts := task.History[len(task.History)-1].Parts
+		for i := range card.Skills {
+			skill := &card.Skills[i]
+			if len(skill.InputModes) == 0 || !acceptsParts(skill.InputModes, parts) {
+				continue
+			}
+
+			if executor := r.executor(skill.Id); executor != nil {
+				return skill, executor, nil
+			}
+		}
+	}
+
+	if r.classifier != nil && len(card.Skills) > 0 {
+		if id, ok := r.classifier(ctx, task, card.Skills); ok {
+			skill, found := card.Skill(id)
+			if executor := r.executor(id); found && executor != nil {
+				return skill, executor, nil
+			}
+		}
+	}
+
+	return nil, r.fallback, nil
+}
+
+// cardFor returns the card the task of ctx is routed against: the card of the caller,
+// which follows [A2AServer.SetAgentCard], or the card of the router outside of a server.
+func (r *SkillRouter) cardFor(ctx context.Context) *protocol.AgentCard {
+	if card, ok := AgentCardFromContext(ctx); ok {
+		return card
+	}
+
+	return &r.card
+}
+
+// executor returns the executor of the skill, nil if not handled.
+func (r *SkillRouter) executor(id string) AgentExecutor {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.executors[id]
+}
+
+var _ AgentExecutor = (*SkillRouter)(nil)
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+// namedExecutor is an executor told apart by its name.
+type namedExecutor string
+
+func (e namedExecutor) Execute(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+	return nil
+}
+
+func TestSkillRouterRoute(t *testing.T) {
+	card := protocol.AgentCard{
+		Name: "test",
+		Skills: []protocol.Skill{
+			{Id: "echo", InputModes: []string{"text"}},
+			{Id: "draw", InputModes: []string{"text"}},
+			{Id: "vision", InputModes: []string{"image/*"}},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		skillID    string
+		classified string
+		want       namedExecutor
+	}{
+		{name: "requested skill", skillID: "draw", want: "draw"},
+		{name: "requested skill without executor", skillID: "vision", want: "fallback"},
+		{name: "requested skill is not reclassified", skillID: "vision", classified: "draw", want: "fallback"},
+		{name: "input modes", want: "echo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewSkillRouter(card,
+				WithFallbackExecutor(namedExecutor("fallback")),
+				WithSkillClassifier(func(ctx context.Context, task *protocol.Task, skills []protocol.Skill) (string, bool) {
+					return tt.classified, tt.classified != ""
+				}))
+			r.Handle("echo", namedExecutor("echo"))
+			r.Handle("draw", namedExecutor("draw"))
+
+			task := &protocol.Task{ID: "t1", History: []protocol.Message{sendParams("t1", "hello").Message}}
+			if tt.skillID != "" {
+				task.Metadata = map[string]any{protocol.MetadataSkillID: tt.skillID}
+			}
+
+			if _, got, _ := r.route(context.Background(), task); got != tt.want {
+				t.Fatalf("routed to %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkillRouterVisibility(t *testing.T) {
+	public := protocol.AgentCard{
+		Name:   "test",
+		Skills: []protocol.Skill{{Id: "echo"}},
+	}
+
+	partner := protocol.Skill{Id: "partner", InputModes: []string{"text"}}
+	extended := public
+	extended.Skills = []protocol.Skill{partner, public.Skills[0]}
+
+	router := NewSkillRouter(extended, WithFallbackExecutor(AgentExecutorFunc(completeTask)))
+	router.Handle("partner", AgentExecutorFunc(func(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+		return updater.Fail(ctx, protocol.NewTextPart("partner"))
+	}))
+
+	s := NewA2AServerWithExecutor(public, router, WithExtendedCard(
+		func(ctx context.Context, principal *Principal, public protocol.AgentCard) (protocol.AgentCard, bool) {
+			return extended, true
+		}))
+
+	alice := ContextWithPrincipal(context.Background(), &Principal{Subject: "alice"})
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		skillID   string
+		wantErr   protocol.ErrorType
+		wantState protocol.TaskState
+	}{
+		{name: "partner skill requested by a partner", ctx: alice, skillID: "partner", wantState: protocol.TaskStateFailed},
+		{name: "partner skill routed by modes for a partner", ctx: alice, wantState: protocol.TaskStateFailed},
+		{name: "partner skill requested anonymously", ctx: context.Background(), skillID: "partner", wantErr: protocol.ErrSkillNotFound},
+		{name: "partner skill not routed by modes anonymously", ctx: context.Background(), wantState: protocol.TaskStateCompleted},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := sendParams(fmt.Sprintf("t%d", i), "hello")
+			if tt.skillID != "" {
+				params.Metadata = map[string]any{protocol.MetadataSkillID: tt.skillID}
+			}
+
+			task, err := s.sendTask(tt.ctx, params)
+			if tt.wantErr != (protocol.ErrorType{}) {
+				if !protocol.Is(err, tt.wantErr) {
+					t.Fatalf("sendTask = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil || task.Status.State != tt.wantState {
+				t.Fatalf("sendTask = %v, %v, want %s", task, err, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestSkillRouterHiddenSkill(t *testing.T) {
+	r := NewSkillRouter(protocol.AgentCard{Skills: []protocol.Skill{{Id: "partner"}}})
+	r.Handle("partner", namedExecutor("partner"))
+
+	ctx := withAgentCard(context.Background(), protocol.AgentCard{Name: "public"})
+	task := &protocol.Task{ID: "t1", Metadata: map[string]any{protocol.MetadataSkillID: "partner"}}
+	if _, _, err := r.route(ctx, task); !protocol.Is(err, protocol.ErrSkillNotFound) {
+		t.Fatalf("route = %v, want ErrSkillNotFound", err)
+	}
+}
+
+func TestSkillRouterSetAgentCard(t *testing.T) {
+	card := protocol.AgentCard{Name: "test", Url: "http://localhost", Version: "1.0.0", Skills: []protocol.Skill{{Id: "echo"}}}
+
+	r := NewSkillRouter(card)
+	r.Handle("draw", AgentExecutorFunc(completeTask))
+	s := NewA2AServerWithExecutor(card, r)
+
+	// no skill matches the message, and there is no fallback.
+	task, err := s.sendTask(context.Background(), sendParams("t1", "hello"))
+	if err != nil || task.Status.State != protocol.TaskStateFailed {
+		t.Fatalf("sendTask before the skill is added = %v, %v, want failed", task, err)
+	}
+
+	card.Skills = append(card.Skills, protocol.Skill{Id: "draw", InputModes: []string{"text"}})
+	if err := s.SetAgentCard(card); err != nil {
+		t.Fatal(err)
+	}
+
+	task, err = s.sendTask(context.Background(), sendParams("t2", "hello"))
+	if err != nil || task.Status.State != protocol.TaskStateCompleted {
+		t.Fatalf("sendTask = %v, %v, want completed", task, err)
+	}
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrStreamClosed       = errors.New("sse stream closed")
+	ErrStreamUnsupported  = errors.New("streaming unsupported by response writer")
+	ErrInvalidEventFields = errors.New("sse id and event must not contain line breaks or NUL")
+)
+
+// SSEEvent is a single Server-Sent Event.
+// See: https://html.spec.whatwg.org/multipage/server-sent-events.html
+type SSEEvent struct {
+	// Sets the last event id of the client, omitted if empty.
+	ID string
+
+	// Event type, omitted if empty (the client defaults to "message").
+	Event string
+
+	// Event payload, which may span multiple lines.
+	Data []byte
+
+	// Reconnection time hint for the client, omitted if zero.
+	Retry time.Duration
+}
+
+// SSEWriter writes Server-Sent Events to an http.ResponseWriter.
+// It is safe for concurrent use, so keep-alive comments can be interleaved with events.
+// Once closed, or once a write fails because the client went away,
+// every following write returns [ErrStreamClosed].
+type SSEWriter struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+	closed  bool
+}
+
+// NewSSEWriter sets the event stream headers and flushes them to the client.
+func NewSSEWriter(w http.ResponseWriter) (*SSEWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, ErrStreamUnsupported
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &SSEWriter{w: w, flusher: flusher}, nil
+}
+
+// WriteEvent writes and flushes the event.
+func (s *SSEWriter) WriteEvent(e SSEEvent) error {
+	if strings.ContainsAny(e.ID, "\r\n\x00") || strings.ContainsAny(e.Event, "\r\n") {
+		return ErrInvalidEventFields
+	}
+
+	buf := new(bytes.Buffer)
+	if e.ID != "" {
+		fmt.Fprintf(buf, "id: %s\n", e.ID)
+	}
+
+	if e.Event != "" {
+		fmt.Fprintf(buf, "event: %s\n", e.Event)
+	}
+
+	if e.Retry > 0 {
+		fmt.Fprintf(buf, "retry: %d\n", e.Retry.Milliseconds())
+	}
+
+	// every line of data gets its own field, the client joins them with '\n'.
+	for _, line := range splitLines(e.Data) {
+		fmt.Fprintf(buf, "data: %s\n", line)
+	}
+
+	buf.WriteByte('\n')
+	return s.write(buf.Bytes())
+}
+
+// WriteRetry writes a standalone reconnection time hint.
+func (s *SSEWriter) WriteRetry(retry time.Duration) error {
+	return s.write([]byte(fmt.Sprintf("retry: %d\n\n", retry.Milliseconds())))
+}
+
+// WriteComment writes a comment, ignored by clients but keeping the connection alive.
+func (s *SSEWriter) WriteComment(comment string) error {
+	buf := new(bytes.Buffer)
+	for _, line := range splitLines([]byte(comment)) {
+		fmt.Fprintf(buf, ":%s\n", line)
+	}
+
+	buf.WriteByte('\n')
+	return s.write(buf.Bytes())
+}
+
+// Close rejects every following write. It doesn't close the underlying connection.
+func (s *SSEWriter) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+}
+
+func (s *SSEWriter) write(p []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStreamClosed
+	}
+
+	if _, err := s.w.Write(p); err != nil {
+		s.closed = true
+		return fmt.Errorf("%w: %v", ErrStreamClosed, err)
+	}
+
+	s.flusher.Flush()
+	return nil
+}
+
+// splitLines splits data on any of the line endings allowed by SSE: "\r\n", "\n" and "\r".
+func splitLines(data []byte) []string {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	return strings.Split(text, "\n")
+}
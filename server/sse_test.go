@@ -0,0 +1,127 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// failingWriter is a response writer whose client went away.
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestSSEWriterEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   SSEEvent
+		want    string
+		wantErr error
+	}{
+		{name: "data only", event: SSEEvent{Data: []byte("hello")}, want: "data: hello\n\n"},
+		{
+			name:  "every field",
+			event: SSEEvent{ID: "1-2", Event: "update", Retry: 3 * time.Second, Data: []byte(`{"id":"t1"}`)},
+			want:  "id: 1-2\nevent: update\nretry: 3000\ndata: {\"id\":\"t1\"}\n\n",
+		},
+		{name: "multi-line data", event: SSEEvent{Data: []byte("a\nb\r\nc\rd")}, want: "data: a\ndata: b\ndata: c\ndata: d\n\n"},
+		{name: "empty data", event: SSEEvent{Event: "ping"}, want: "event: ping\ndata: \n\n"},
+		{name: "line break in id", event: SSEEvent{ID: "1\n2"}, wantErr: ErrInvalidEventFields},
+		{name: "carriage return in id", event: SSEEvent{ID: "1\r2"}, wantErr: ErrInvalidEventFields},
+		{name: "NUL in id", event: SSEEvent{ID: "1\x002"}, wantErr: ErrInvalidEventFields},
+		{name: "line break in event", event: SSEEvent{Event: "a\nb"}, wantErr: ErrInvalidEventFields},
+		{name: "carriage return in event", event: SSEEvent{Event: "a\rb"}, wantErr: ErrInvalidEventFields},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			w, err := NewSSEWriter(rec)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := w.WriteEvent(tt.event); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WriteEvent = %v, want %v", err, tt.wantErr)
+			}
+
+			if got := rec.Body.String(); got != tt.want {
+				t.Fatalf("wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSSEWriterCommentAndRetry(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w, err := NewSSEWriter(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := rec.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", got)
+	}
+
+	if err := w.WriteComment("keep-alive\nstill here"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.WriteRetry(1500 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := ":keep-alive\n:still here\n\nretry: 1500\n\n"; rec.Body.String() != want {
+		t.Fatalf("wrote %q, want %q", rec.Body.String(), want)
+	}
+}
+
+func TestSSEWriterClosed(t *testing.T) {
+	tests := []struct {
+		name  string
+		w     http.ResponseWriter
+		close bool
+	}{
+		{name: "closed", w: httptest.NewRecorder(), close: true},
+		{name: "failed write", w: failingWriter{httptest.NewRecorder()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := NewSSEWriter(tt.w)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.close {
+				w.Close()
+			} else if err := w.WriteComment("first"); !errors.Is(err, ErrStreamClosed) {
+				t.Fatalf("WriteComment = %v, want ErrStreamClosed", err)
+			}
+
+			for name, write := range map[string]func() error{
+				"WriteEvent":   func() error { return w.WriteEvent(SSEEvent{Data: []byte("hello")}) },
+				"WriteComment": func() error { return w.WriteComment("ping") },
+				"WriteRetry":   func() error { return w.WriteRetry(time.Second) },
+			} {
+				if err := write(); !errors.Is(err, ErrStreamClosed) {
+					t.Fatalf("%s = %v, want ErrStreamClosed", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSSEWriterUnsupported(t *testing.T) {
+	// a writer without Flush can't stream.
+	w := struct{ http.ResponseWriter }{httptest.NewRecorder()}
+	if _, err := NewSSEWriter(w); !errors.Is(err, ErrStreamUnsupported) {
+		t.Fatalf("NewSSEWriter = %v, want ErrStreamUnsupported", err)
+	}
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+func TestStatusHistory(t *testing.T) {
+	tests := []struct {
+		name          string
+		transitions   bool
+		historyLength *int
+		maxHistory    int
+		want          []protocol.TaskState
+	}{
+		{name: "not recorded without the capability"},
+		{
+			name: "every status change", transitions: true,
+			want: []protocol.TaskState{
+				protocol.TaskStateSubmitted, protocol.TaskStateWorking, protocol.TaskStateWorking, protocol.TaskStateCompleted,
+			},
+		},
+		{
+			name: "trimmed by history length", transitions: true, historyLength: ptr(2),
+			want: []protocol.TaskState{protocol.TaskStateWorking, protocol.TaskStateCompleted},
+		},
+		{name: "history length 0", transitions: true, historyLength: ptr(0)},
+		{
+			name: "capped by the retention policy", transitions: true, maxHistory: 3,
+			want: []protocol.TaskState{protocol.TaskStateWorking, protocol.TaskStateWorking, protocol.TaskStateCompleted},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := protocol.AgentCard{Name: "test"}
+			card.Capabilities.StateTransitionHistory = ptr(tt.transitions)
+
+			var opts []Option
+			if tt.maxHistory > 0 {
+				opts = append(opts, WithRetention(RetentionPolicy{MaxHistory: tt.maxHistory}))
+			}
+
+			m := NewA2AServerWithExecutor(card, AgentExecutorFunc(
+				func(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+					if err := updater.Working(ctx, protocol.NewTextPart("thinking")); err != nil {
+						return err
+					}
+
+					return updater.Complete(ctx)
+				}), opts...).handler.(*taskManager)
+
+			ctx := context.Background()
+			if _, err := m.SendTask(ctx, sendParams("t1", "hello")); err != nil {
+				t.Fatalf("SendTask = %v", err)
+			}
+			waitDone(t, m, "t1")
+
+			task, err := m.GetTask(ctx, &protocol.TaskSendParams{ID: "t1", HistoryLength: tt.historyLength})
+			if err != nil {
+				t.Fatalf("GetTask = %v", err)
+			}
+
+			var got []protocol.TaskState
+			for _, status := range task.StatusHistory {
+				got = append(got, status.State)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("status history = %v, want %v", got, tt.want)
+			}
+
+			// the recorded changes keep their message.
+			if len(got) == 4 {
+				if msg := task.StatusHistory[2].Message; msg == nil || msg.Parts[0].Text != "thinking" {
+					t.Fatalf("working message = %+v, want thinking", msg)
+				}
+			}
+		})
+	}
+}
+
+func TestStatusTimestamp(t *testing.T) {
+	card := protocol.AgentCard{Name: "test"}
+	card.Capabilities.StateTransitionHistory = ptr(true)
+
+	m := NewA2AServerWithExecutor(card, AgentExecutorFunc(
+		func(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+			return updater.Complete(ctx)
+		})).handler.(*taskManager)
+
+	before := time.Now().Truncate(time.Millisecond)
+
+	ctx := context.Background()
+	task, err := m.SendTask(ctx, sendParams("t1", "hello"))
+	if err != nil {
+		t.Fatalf("SendTask = %v", err)
+	}
+
+	after := time.Now()
+
+	for _, status := range append(task.StatusHistory, task.Status) {
+		if status.Timestamp == nil {
+			t.Fatalf("status %s has no timestamp", status.State)
+		}
+
+		// ISO 8601 in UTC, with milliseconds.
+		stamp := *status.Timestamp
+		if !strings.HasSuffix(stamp, "Z") || len(stamp) != len("2006-01-02T15:04:05.000Z") {
+			t.Fatalf("timestamp %q is not UTC in milliseconds", stamp)
+		}
+
+		at, err := time.Parse(protocol.TimestampFormat, stamp)
+		if err != nil {
+			t.Fatalf("timestamp %q: %v", stamp, err)
+		}
+
+		if at.Before(before) || at.After(after) {
+			t.Fatalf("timestamp %s not within the send [%s, %s]", at, before, after)
+		}
+	}
+}
@@ -0,0 +1,710 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+type (
+	// taskManager implements protocol.IA2AProtocol on top of an AgentExecutor:
+	// it keeps the tasks in the task store, runs the executor,
+	// and fans the updates out to stream subscribers and push notifications.
+	taskManager struct {
+		card     protocol.AgentCard
+		executor AgentExecutor
+		store    ITaskStore
+		notifier *pushNotifier
+		pool     *workerPool
+
+		// records the status changes of the tasks, see [protocol.Capabilities.StateTransitionHistory].
+		transitions bool
+
+		// retention of the terminal tasks, evicted is called for every task evicted.
+		retention *retention
+		evicted   func(taskID, sessionID string)
+
+		// visibility of the tasks in tasks/list, nil if not served.
+		// owners holds the subject of the creator of each task while served, guarded by mu.
+		scope  TaskScope
+		owners map[string]string
+
+		// the agent card of the caller of ctx, passed on to the executor.
+		cardFor func(ctx context.Context) protocol.AgentCard
+
+		// bounds [TaskUpdater.WaitForInput], zero if unbounded.
+		inputTimeout time.Duration
+
+		// serializes read-modify-write of tasks in the store.
+		mu      sync.Mutex
+		running map[string]*execution
+	}
+
+	// execution is a running task and the subscribers of its updates.
+	execution struct {
+		done chan struct{}
+
+		// ctx of the executor, canceled by tasks/cancel.
+		ctx    context.Context
+		cancel context.CancelFunc
+
+		// the worker of the execution, the task is submitted until it is granted.
+		// It is given back while waiting for input, see [TaskUpdater.WaitForInput].
+		ticket   *poolTicket
+		priority int
+		skill    string
+
+		// the follow-up message of a task waiting for input, see [TaskUpdater.WaitForInput].
+		// waiting is guarded by taskManager.mu.
+		input   chan protocol.Message
+		waiting bool
+
+		// the output modes accepted by the client, with the last message. Guarded by taskManager.mu.
+		accepted []string
+
+		mu   sync.Mutex
+		subs map[*taskSub]struct{}
+
+		// closed, and replaced, every time the task reaches a final state.
+		turn  chan struct{}
+		ended bool
+	}
+
+	taskSub struct {
+		ch   chan any
+		ctx  context.Context
+		stop func() bool
+	}
+
+	// taskTurn is a message sent to a task, up to the next final state of the task.
+	taskTurn struct {
+		task *protocol.Task
+		exec *execution
+
+		// true if the message resumed an execution waiting for input.
+		resumed bool
+
+		// true if the message created the task.
+		created bool
+
+		// closed when the task reaches its next final state.
+		final <-chan struct{}
+
+		// updates of the task, if subscribed.
+		events chan any
+	}
+)
+
+func newTaskManager(card protocol.AgentCard, executor AgentExecutor, s *A2AServer) *taskManager {
+	store := s.store
+	if store == nil {
+		store = NewMemoryTaskStore()
+	}
+
+	m := &taskManager{
+		card:     card,
+		executor: executor,
+		store:    store,
+		notifier: newPushNotifier(s.pushPolicy, s.pushSigner),
+		pool:     s.pool,
+		evicted:  s.forget,
+		cardFor:  s.AgentCardFor,
+		running:  make(map[string]*execution),
+
+		inputTimeout: s.inputTimeout,
+	}
+
+	if v := card.Capabilities.StateTransitionHistory; v != nil && *v {
+		m.transitions = true
+	}
+
+	if s.retention != nil {
+		m.retention = newRetention(*s.retention, m.evict)
+	}
+
+	if s.listTasks {
+		m.scope = s.taskScope
+		m.owners = make(map[string]string)
+	}
+
+	return m
+}
+
+// AgentCard implements protocol.IA2AProtocol.
+func (m *taskManager) AgentCard() protocol.AgentCard {
+	return m.card
+}
+
+// SendTask implements protocol.IA2AProtocol.
+// It blocks until the task reaches a final state or ctx is done, then returns the task.
+func (m *taskManager) SendTask(ctx context.Context, params *protocol.TaskSendParams) (*protocol.Task, error) {
+	return m.send(ctx, params, true, 0)
+}
+
+// send starts the task, then returns it once it reaches a final state if wait is set,
+// or after timeout if positive. Otherwise it is returned as soon as it is submitted.
+func (m *taskManager) send(ctx context.Context, params *protocol.TaskSendParams, wait bool, timeout time.Duration) (*protocol.Task, error) {
+	turn, err := m.begin(ctx, params, false)
+	if err != nil {
+		return nil, err
+	}
+
+	m.start(turn)
+
+	if wait {
+		var expired <-chan time.Time
+		if timeout > 0 {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			expired = timer.C
+		}
+
+		select {
+		case <-turn.final:
+		case <-turn.exec.done:
+		case <-expired:
+		case <-ctx.Done():
+		}
+	}
+
+	return m.snapshot(ctx, params.ID, params.HistoryLength)
+}
+
+// SubscribeTask implements protocol.IA2AProtocol.
+func (m *taskManager) SubscribeTask(ctx context.Context, params *protocol.TaskSendParams) (chan any, error) {
+	turn, err := m.begin(ctx, params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	m.start(turn)
+
+	return turn.events, nil
+}
+
+// ResubscribeTask implements protocol.IA2AProtocol.
+// A task not running anymore gets its current status as the single, final, event.
+func (m *taskManager) ResubscribeTask(ctx context.Context, params *protocol.TaskSendParams) (chan any, error) {
+	m.mu.Lock()
+	exec, ok := m.running[params.ID]
+	m.mu.Unlock()
+
+	if ok {
+		return exec.subscribe(ctx), nil
+	}
+
+	task, err := m.store.GetTask(ctx, params.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan any, 1)
+	ch <- &protocol.TaskStatusUpdateEvent{
+		ID:     task.ID,
+		Status: task.Status,
+		Final:  true,
+	}
+	close(ch)
+
+	return ch, nil
+}
+
+// GetTask implements protocol.IA2AProtocol.
+func (m *taskManager) GetTask(ctx context.Context, params *protocol.TaskSendParams) (*protocol.Task, error) {
+	return m.snapshot(ctx, params.ID, params.HistoryLength)
+}
+
+// CancelTask implements protocol.IA2AProtocol.
+// The task is moved to canceled, which sends the final status to its subscribers,
+// then the ctx of its executor is canceled.
+func (m *taskManager) CancelTask(ctx context.Context, params *protocol.TaskSendParams) (*protocol.Task, error) {
+	err := m.updateStatus(ctx, params.ID, protocol.TaskStateCanceled, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	exec, ok := m.running[params.ID]
+	m.mu.Unlock()
+
+	if ok {
+		exec.cancel()
+	}
+
+	return m.snapshot(ctx, params.ID, params.HistoryLength)
+}
+
+// SetTaskPushNotifications implements protocol.IA2AProtocol.
+func (m *taskManager) SetTaskPushNotifications(ctx context.Context, params *protocol.TaskPushNotificationConfig) (*protocol.TaskPushNotificationConfig, error) {
+	if _, err := m.store.GetTask(ctx, params.ID); err != nil {
+		return nil, err
+	}
+
+	err := m.store.SavePushNotification(ctx, params.ID, &params.PushNotificationConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// the pending notifications were meant for the previous url.
+	m.notifier.cancel(params.ID)
+	return params, nil
+}
+
+// GetTaskPushNotifications implements protocol.IA2AProtocol.
+func (m *taskManager) GetTaskPushNotifications(ctx context.Context, params *protocol.TaskPushNotificationConfig) (*protocol.TaskPushNotificationConfig, error) {
+	if _, err := m.store.GetTask(ctx, params.ID); err != nil {
+		return nil, err
+	}
+
+	config, err := m.store.GetPushNotification(ctx, params.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &protocol.TaskPushNotificationConfig{
+		ID:                     params.ID,
+		PushNotificationConfig: *config,
+	}, nil
+}
+
+// begin creates the task, or continues an existing one, with the message received.
+// A new execution is registered, unless the task is waiting for input, in which case
+// the message is handed over to its execution.
+//
+// The turn is observed, and subscribed if requested, before the message is handed over,
+// so no update caused by the message is missed.
+func (m *taskManager) begin(ctx context.Context, params *protocol.TaskSendParams, subscribe bool) (_ *taskTurn, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, err := m.store.GetTask(ctx, params.ID)
+	created := protocol.Is(err, protocol.ErrTaskNotFound)
+	if created {
+		task = &protocol.Task{
+			ID:       params.ID,
+			Metadata: params.Metadata,
+		}
+
+		if params.SessionID != nil {
+			task.SessionID = *params.SessionID
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if task.Status.State.IsTerminal() {
+		return nil, protocol.ErrTaskTerminated.New().Args(task.ID, task.Status.State)
+	}
+
+	exec, running := m.running[params.ID]
+	if running && !exec.waiting {
+		return nil, protocol.ErrTaskRunning.New().Args(params.ID)
+	}
+
+	var ticket *poolTicket
+	if !running {
+		ticket, err = m.pool.enqueue(taskPriority(params.Metadata))
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err != nil {
+				ticket.release()
+			}
+		}()
+	}
+
+	task.History = m.retention.trim(append(task.History, params.Message))
+	if !running {
+		m.setStatus(task, protocol.TaskStatus{State: protocol.TaskStateSubmitted})
+	}
+
+	if params.PushNotification != nil {
+		err = m.store.SavePushNotification(ctx, params.ID, params.PushNotification)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = m.store.SaveTask(ctx, task); err != nil {
+		return nil, err
+	}
+
+	if created && m.owners != nil {
+		m.owners[task.ID] = subject(ctx)
+	}
+
+	if !running {
+		exec = &execution{
+			done:     make(chan struct{}),
+			ticket:   ticket,
+			priority: taskPriority(params.Metadata),
+			skill:    taskSkill(params.Metadata),
+			input:    make(chan protocol.Message, 1),
+			subs:     make(map[*taskSub]struct{}),
+			turn:     make(chan struct{}),
+		}
+
+		// the executor outlives the request, keep only the session and the card of the caller of ctx.
+		execCtx := withAgentCard(context.Background(), m.cardFor(ctx))
+		if session, ok := SessionFromContext(ctx); ok {
+			execCtx = withSession(execCtx, session)
+		}
+
+		exec.ctx, exec.cancel = context.WithCancel(execCtx)
+		m.running[params.ID] = exec
+	}
+
+	exec.accepted = params.AcceptedOutputModes
+
+	turn := &taskTurn{
+		task:    task,
+		exec:    exec,
+		resumed: running,
+		created: created,
+		final:   exec.nextTurn(),
+	}
+
+	if subscribe {
+		turn.events = exec.subscribe(ctx)
+	}
+
+	if running {
+		// never blocks, a waiting execution has no pending input.
+		exec.waiting = false
+		exec.input <- params.Message
+	}
+
+	return turn, nil
+}
+
+// start runs the execution of the turn if new, and applies the retention caps to a new task.
+func (m *taskManager) start(turn *taskTurn) {
+	if !turn.resumed {
+		go m.run(turn.task, turn.exec)
+	}
+
+	if turn.created {
+		m.evict(m.retention.track(turn.task))
+	}
+}
+
+// evict deletes the tasks from the store, and lets the server forget them.
+func (m *taskManager) evict(tasks []*retainedTask) {
+	for _, t := range tasks {
+		m.mu.Lock()
+		err := m.store.DeleteTask(context.Background(), t.id)
+		if err == nil && m.owners != nil {
+			delete(m.owners, t.id)
+		}
+		m.mu.Unlock()
+
+		if err != nil {
+			continue
+		}
+
+		m.evicted(t.id, t.session)
+	}
+}
+
+// run waits for a worker, then executes the task, moving it to a final state if the executor doesn't.
+// If the executor returns while the task is waiting for input and the input arrives,
+// it is executed again with the updated task.
+func (m *taskManager) run(task *protocol.Task, exec *execution) {
+	defer func() {
+		exec.ticket.release()
+		exec.cancel()
+		exec.end()
+		close(exec.done)
+	}()
+
+	// canceled while queued.
+	if exec.ticket.wait(exec.ctx) != nil {
+		m.release(task.ID)
+		return
+	}
+
+	ctx := exec.ctx
+	if timeout := m.pool.timeout(exec.skill); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	updater := &TaskUpdater{m: m, taskID: task.ID, exec: exec}
+
+	err := updater.Working(ctx)
+	if err != nil {
+		m.release(task.ID)
+		return
+	}
+
+	for {
+		err = m.execute(ctx, task, updater)
+		if !updater.finished() {
+			// ctx may be done, e.g. on timeout, the task must reach its final state anyway.
+			uctx := context.WithoutCancel(ctx)
+			if err != nil {
+				updater.Fail(uctx, protocol.NewTextPart(err.Error()))
+			} else {
+				updater.Complete(uctx)
+			}
+		}
+
+		m.mu.Lock()
+		select {
+		case <-exec.input:
+			// the input arrived after the executor returned, execute again.
+			m.mu.Unlock()
+		default:
+			delete(m.running, task.ID)
+			m.mu.Unlock()
+			return
+		}
+
+		if err = updater.resume(ctx); err != nil {
+			m.release(task.ID)
+			return
+		}
+
+		task, err = m.store.GetTask(ctx, updater.taskID)
+		if err != nil {
+			m.release(updater.taskID)
+			return
+		}
+	}
+}
+
+func (m *taskManager) release(taskID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.running, taskID)
+}
+
+// execute runs the executor, turning a panic into an error.
+func (m *taskManager) execute(ctx context.Context, task *protocol.Task, updater *TaskUpdater) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("executor panic: %v", r)
+		}
+	}()
+
+	return m.executor.Execute(ctx, task, updater)
+}
+
+func (m *taskManager) updateStatus(ctx context.Context, taskID string, state protocol.TaskState, message *protocol.Message) error {
+	m.mu.Lock()
+	task, err := m.store.GetTask(ctx, taskID)
+	if err != nil {
+		m.mu.Unlock()
+		return err
+	}
+
+	if task.Status.State.IsTerminal() {
+		m.mu.Unlock()
+		if state == protocol.TaskStateCanceled {
+			return protocol.ErrTaskCannotCancel.New().Args(taskID, task.Status.State)
+		}
+
+		return ErrTaskFinished
+	}
+
+	m.setStatus(task, protocol.TaskStatus{
+		State:   state,
+		Message: message,
+	})
+
+	if message != nil {
+		task.History = m.retention.trim(append(task.History, *message))
+	}
+
+	err = m.store.SaveTask(ctx, task)
+	if exec, ok := m.running[taskID]; ok && err == nil {
+		exec.waiting = state == protocol.TaskStateInputRequired
+	}
+	m.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if state.IsTerminal() {
+		m.retention.finish(taskID)
+	}
+
+	final := isFinalState(state)
+	m.publish(ctx, taskID, &protocol.TaskStatusUpdateEvent{
+		ID:     taskID,
+		Status: task.Status,
+		Final:  final,
+	}, final)
+
+	return nil
+}
+
+// addArtifact adds the artifact to the task, [ErrTaskFinished] is returned if the task is terminal,
+// e.g. canceled while the executor is still working.
+func (m *taskManager) addArtifact(ctx context.Context, taskID string, artifact protocol.Artifact) error {
+	m.mu.Lock()
+	task, err := m.store.GetTask(ctx, taskID)
+	if err != nil {
+		m.mu.Unlock()
+		return err
+	}
+
+	if task.Status.State.IsTerminal() {
+		m.mu.Unlock()
+		return ErrTaskFinished
+	}
+
+	merged := false
+	if artifact.Append != nil && *artifact.Append {
+		for i := range task.Artifacts {
+			if task.Artifacts[i].Index == artifact.Index {
+				prev := &task.Artifacts[i]
+				prev.Parts = append(append([]protocol.Part(nil), prev.Parts...), artifact.Parts...)
+				prev.LastChunk = artifact.LastChunk
+				merged = true
+				break
+			}
+		}
+	}
+
+	if !merged {
+		task.Artifacts = append(task.Artifacts, artifact)
+	}
+
+	err = m.store.SaveTask(ctx, task)
+	m.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	m.publish(ctx, taskID, &protocol.TaskArtifactUpdateEvent{
+		ID:       taskID,
+		Artifact: artifact,
+	}, false)
+
+	return nil
+}
+
+// publish sends the event to the stream subscribers and the push notification url of the task.
+func (m *taskManager) publish(ctx context.Context, taskID string, event any, final bool) {
+	m.mu.Lock()
+	exec, ok := m.running[taskID]
+	m.mu.Unlock()
+
+	if ok {
+		exec.publish(event, final)
+	}
+
+	config, err := m.store.GetPushNotification(ctx, taskID)
+	if err == nil {
+		m.notifier.notify(taskID, *config, event, final)
+	}
+}
+
+// setStatus stamps the status, sets it to the task and records it in its status history if enabled.
+func (m *taskManager) setStatus(task *protocol.Task, status protocol.TaskStatus) {
+	status.Timestamp = timestamp(time.Now())
+	task.Status = status
+
+	if m.transitions {
+		task.StatusHistory = m.retention.trimStatus(append(task.StatusHistory, status))
+	}
+}
+
+// snapshot returns the task with at most historyLength recent messages and status changes, all if nil.
+func (m *taskManager) snapshot(ctx context.Context, taskID string, historyLength *int) (*protocol.Task, error) {
+	task, err := m.store.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	trimHistory(task, historyLength)
+	return task, nil
+}
+
+// timestamp formats t as a [protocol.TaskStatus] timestamp.
+func timestamp(t time.Time) *string {
+	ret := t.UTC().Format(protocol.TimestampFormat)
+	return &ret
+}
+
+// subscribe returns a channel receiving the updates of the execution,
+// closed once the execution ends or ctx is done.
+func (e *execution) subscribe(ctx context.Context) chan any {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ch := make(chan any)
+	if e.ended {
+		close(ch)
+		return ch
+	}
+
+	sub := &taskSub{ch: ch, ctx: ctx}
+	sub.stop = context.AfterFunc(ctx, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		if _, ok := e.subs[sub]; ok {
+			delete(e.subs, sub)
+			close(sub.ch)
+		}
+	})
+
+	e.subs[sub] = struct{}{}
+	return ch
+}
+
+// publish sends the event to the subscribers, a final event ends their streams.
+func (e *execution) publish(event any, final bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for sub := range e.subs {
+		select {
+		case sub.ch <- event:
+		case <-sub.ctx.Done():
+		}
+	}
+
+	if final {
+		e.closeSubs()
+		close(e.turn)
+		e.turn = make(chan struct{})
+	}
+}
+
+// nextTurn returns the channel closed when the task reaches its next final state.
+func (e *execution) nextTurn() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.turn
+}
+
+func (e *execution) end() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.ended = true
+	e.closeSubs()
+}
+
+func (e *execution) closeSubs() {
+	for sub := range e.subs {
+		sub.stop()
+		delete(e.subs, sub)
+		close(sub.ch)
+	}
+}
+
+var _ protocol.IA2AProtocol = (*taskManager)(nil)
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+// ITaskStore persists the tasks, and their push notification configs, of an [AgentExecutor].
+// Implementations must be safe for concurrent use.
+type ITaskStore interface {
+	// GetTask returns the task, or [protocol.ErrTaskNotFound] if it doesn't exist.
+	GetTask(ctx context.Context, id string) (*protocol.Task, error)
+
+	// SaveTask creates or replaces the task.
+	SaveTask(ctx context.Context, task *protocol.Task) error
+
+	// GetPushNotification returns the push notification config of the task,
+	// or [protocol.ErrPushNotificationNotFound] if it isn't configured.
+	GetPushNotification(ctx context.Context, id string) (*protocol.PushNotificationConfig, error)
+
+	// SavePushNotification creates or replaces the push notification config of the task.
+	SavePushNotification(ctx context.Context, id string, config *protocol.PushNotificationConfig) error
+
+	// DeleteTask deletes the task and its push notification config, if they exist.
+	DeleteTask(ctx context.Context, id string) error
+}
+
+// IListableTaskStore is implemented by the task stores able to list their tasks, required by tasks/list,
+// see [WithListTasksMethod].
+type IListableTaskStore interface {
+	ITaskStore
+
+	// ListTasks returns the tasks for which match returns true, in any order.
+	// match must not modify the task.
+	ListTasks(ctx context.Context, match func(task *protocol.Task) bool) ([]*protocol.Task, error)
+}
+
+// NewMemoryTaskStore returns an [ITaskStore] keeping everything in memory.
+func NewMemoryTaskStore() ITaskStore {
+	return &memoryTaskStore{
+		tasks:  make(map[string]*protocol.Task),
+		pushes: make(map[string]*protocol.PushNotificationConfig),
+	}
+}
+
+type memoryTaskStore struct {
+	mu     sync.RWMutex
+	tasks  map[string]*protocol.Task
+	pushes map[string]*protocol.PushNotificationConfig
+}
+
+// GetTask implements ITaskStore.
+func (m *memoryTaskStore) GetTask(ctx context.Context, id string) (*protocol.Task, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	task, ok := m.tasks[id]
+	if !ok {
+		return nil, protocol.ErrTaskNotFound.New().Args(id)
+	}
+
+	return cloneTask(task), nil
+}
+
+// SaveTask implements ITaskStore.
+func (m *memoryTaskStore) SaveTask(ctx context.Context, task *protocol.Task) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tasks[task.ID] = cloneTask(task)
+	return nil
+}
+
+// GetPushNotification implements ITaskStore.
+func (m *memoryTaskStore) GetPushNotification(ctx context.Context, id string) (*protocol.PushNotificationConfig, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	config, ok := m.pushes[id]
+	if !ok {
+		return nil, protocol.ErrPushNotificationNotFound.New().Args(id)
+	}
+
+	ret := *config
+	return &ret, nil
+}
+
+// SavePushNotification implements ITaskStore.
+func (m *memoryTaskStore) SavePushNotification(ctx context.Context, id string, config *protocol.PushNotificationConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ret := *config
+	m.pushes[id] = &ret
+	return nil
+}
+
+// DeleteTask implements ITaskStore.
+func (m *memoryTaskStore) DeleteTask(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.tasks, id)
+	delete(m.pushes, id)
+	return nil
+}
+
+// ListTasks implements IListableTaskStore.
+func (m *memoryTaskStore) ListTasks(ctx context.Context, match func(task *protocol.Task) bool) ([]*protocol.Task, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var ret []*protocol.Task
+	for _, task := range m.tasks {
+		if match(task) {
+			ret = append(ret, cloneTask(task))
+		}
+	}
+
+	return ret, nil
+}
+
+// cloneTask copies the task deep enough for the copy to be modified without affecting the original.
+func cloneTask(task *protocol.Task) *protocol.Task {
+	ret := *task
+	ret.History = append([]protocol.Message(nil), task.History...)
+	ret.Artifacts = append([]protocol.Artifact(nil), task.Artifacts...)
+	ret.StatusHistory = append([]protocol.TaskStatus(nil), task.StatusHistory...)
+
+	if task.Metadata != nil {
+		ret.Metadata = make(map[string]any, len(task.Metadata))
+		for k, v := range task.Metadata {
+			ret.Metadata[k] = v
+		}
+	}
+
+	return &ret
+}
+
+var _ IListableTaskStore = (*memoryTaskStore)(nil)
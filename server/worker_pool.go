@@ -0,0 +1,220 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+// WorkerPoolPolicy bounds how many tasks are worked on at the same time, see [WithWorkerPool].
+//
+// A task over the limit waits in the queue, in the submitted state, ordered by its
+// [protocol.MetadataPriority] then by arrival. A task over the queue depth is rejected with
+// [protocol.ErrServerBusy].
+type WorkerPoolPolicy struct {
+	// Workers is the maximum number of tasks worked on at the same time. Default is 16.
+	Workers int
+
+	// QueueDepth is the maximum number of tasks waiting for a worker, 0 rejects as soon as
+	// every worker is busy.
+	QueueDepth int
+
+	// Timeout bounds the work on a task, from the time it leaves the queue. Default is no timeout.
+	Timeout time.Duration
+
+	// SkillTimeouts overrides Timeout for the tasks requesting a skill, by [protocol.MetadataSkillID].
+	SkillTimeouts map[string]time.Duration
+
+	// RetryAfter is the time a rejected client is told to wait before retrying. Default is 1 second.
+	RetryAfter time.Duration
+}
+
+type (
+	// workerPool implements WorkerPoolPolicy, a nil pool is unbounded.
+	workerPool struct {
+		policy WorkerPoolPolicy
+
+		mu      sync.Mutex
+		seq     uint64
+		running int
+		queue   []*poolTicket
+	}
+
+	// poolTicket is the place of a task in the pool, either queued or holding a worker.
+	poolTicket struct {
+		pool     *workerPool
+		priority int
+		seq      uint64
+
+		// closed once the ticket holds a worker.
+		ready chan struct{}
+
+		// guarded by pool.mu.
+		granted  bool
+		released bool
+	}
+)
+
+func newWorkerPool(policy WorkerPoolPolicy) *workerPool {
+	if policy.Workers <= 0 {
+		policy.Workers = 16
+	}
+
+	if policy.QueueDepth < 0 {
+		policy.QueueDepth = 0
+	}
+
+	if policy.RetryAfter <= 0 {
+		policy.RetryAfter = time.Second
+	}
+
+	return &workerPool{policy: policy}
+}
+
+// enqueue places a task in the pool, it never blocks.
+// [protocol.ErrServerBusy] is returned if the queue is full.
+func (p *workerPool) enqueue(priority int) (*poolTicket, error) {
+	t := &poolTicket{pool: p, priority: priority, ready: make(chan struct{})}
+	if p == nil {
+		t.granted = true
+		close(t.ready)
+		return t, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.running < p.policy.Workers && len(p.queue) == 0 {
+		p.running++
+		t.granted = true
+		close(t.ready)
+		return t, nil
+	}
+
+	if len(p.queue) >= p.policy.QueueDepth {
+		return nil, protocol.ErrServerBusy.New().
+			Args(p.policy.RetryAfter).
+			Data(protocol.ServerBusyData{RetryAfter: int(math.Ceil(p.policy.RetryAfter.Seconds()))})
+	}
+
+	p.place(t)
+	return t, nil
+}
+
+// place grants a worker to the ticket if one is free and nobody is queued, or queues it.
+// Must be called with p.mu held.
+func (p *workerPool) place(t *poolTicket) {
+	if p.running < p.policy.Workers && len(p.queue) == 0 {
+		p.running++
+		t.granted = true
+		close(t.ready)
+		return
+	}
+
+	p.seq++
+	t.seq = p.seq
+
+	// higher priority first, then first in first out.
+	i, _ := slices.BinarySearchFunc(p.queue, t, func(a, b *poolTicket) int {
+		if a.priority != b.priority {
+			return cmp.Compare(b.priority, a.priority)
+		}
+
+		return cmp.Compare(a.seq, b.seq)
+	})
+	p.queue = slices.Insert(p.queue, i, t)
+}
+
+// requeue places a task already admitted back in the pool, e.g. resumed after waiting for input.
+// Unlike [workerPool.enqueue], the queue depth doesn't apply.
+func (p *workerPool) requeue(priority int) *poolTicket {
+	t := &poolTicket{pool: p, priority: priority, ready: make(chan struct{})}
+	if p == nil {
+		t.granted = true
+		close(t.ready)
+		return t
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.place(t)
+	return t
+}
+
+// timeout returns the time the work on a task requesting the skill is allowed to take, 0 if unbounded.
+func (p *workerPool) timeout(skill string) time.Duration {
+	if p == nil {
+		return 0
+	}
+
+	if d, ok := p.policy.SkillTimeouts[skill]; ok {
+		return d
+	}
+
+	return p.policy.Timeout
+}
+
+// wait blocks until the ticket holds a worker or ctx is done.
+func (t *poolTicket) wait(ctx context.Context) error {
+	select {
+	case <-t.ready:
+		return nil
+	case <-ctx.Done():
+		// the worker may have been granted meanwhile, release does the right thing either way.
+		return ctx.Err()
+	}
+}
+
+// release gives the worker back, or leaves the queue. It is safe to call more than once.
+func (t *poolTicket) release() {
+	p := t.pool
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if t.released {
+		return
+	}
+
+	t.released = true
+	if !t.granted {
+		p.queue = slices.DeleteFunc(p.queue, func(q *poolTicket) bool { return q == t })
+		return
+	}
+
+	p.running--
+	for p.running < p.policy.Workers && len(p.queue) > 0 {
+		next := p.queue[0]
+		p.queue = p.queue[1:]
+		p.running++
+		next.granted = true
+		close(next.ready)
+	}
+}
+
+// taskPriority returns the [protocol.MetadataPriority] of the task.
+func taskPriority(metadata map[string]any) int {
+	switch v := metadata[protocol.MetadataPriority].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+
+	return 0
+}
+
+// taskSkill returns the [protocol.MetadataSkillID] of the task.
+func taskSkill(metadata map[string]any) string {
+	skill, _ := metadata[protocol.MetadataSkillID].(string)
+	return skill
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/zhengrenjie/go-a2a/protocol"
+)
+
+func TestWorkerPoolOrder(t *testing.T) {
+	p := newWorkerPool(WorkerPoolPolicy{Workers: 1, QueueDepth: 3})
+
+	running, err := p.enqueue(0)
+	if err != nil {
+		t.Fatalf("enqueue = %v", err)
+	}
+
+	var granted []int
+	tickets := map[int]*poolTicket{}
+	for i, priority := range []int{0, 5, 1} {
+		ticket, err := p.enqueue(priority)
+		if err != nil {
+			t.Fatalf("enqueue %d = %v", i, err)
+		}
+
+		tickets[i] = ticket
+	}
+
+	if _, err := p.enqueue(0); !protocol.Is(err, protocol.ErrServerBusy) {
+		t.Fatalf("enqueue over the queue depth = %v, want ErrServerBusy", err)
+	}
+
+	// a task already admitted is queued even over the queue depth.
+	tickets[3] = p.requeue(9)
+
+	running.release()
+	for len(granted) < len(tickets) {
+		for i, ticket := range tickets {
+			if !slices.Contains(granted, i) && ready(ticket) {
+				granted = append(granted, i)
+				ticket.release()
+			}
+		}
+	}
+
+	if want := []int{3, 1, 2, 0}; !slices.Equal(granted, want) {
+		t.Fatalf("granted %v, want %v", granted, want)
+	}
+}
+
+// ready reports whether the ticket holds a worker, without waiting.
+func ready(t *poolTicket) bool {
+	select {
+	case <-t.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestWorkerPoolWaitForInput(t *testing.T) {
+	m := newTestManager(func(ctx context.Context, task *protocol.Task, updater *TaskUpdater) error {
+		if task.ID != "waiting" {
+			return updater.Complete(ctx)
+		}
+
+		if err := updater.RequireInput(ctx); err != nil {
+			return err
+		}
+
+		if _, err := updater.WaitForInput(ctx); err != nil {
+			return err
+		}
+
+		return updater.Complete(ctx)
+	}, WithWorkerPool(WorkerPoolPolicy{Workers: 1}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	task, err := m.SendTask(ctx, sendParams("waiting", "hello"))
+	if err != nil || task.Status.State != protocol.TaskStateInputRequired {
+		t.Fatalf("SendTask = %v, %v, want input-required", task, err)
+	}
+
+	// the only worker is free while the first task waits for input.
+	task, err = m.SendTask(ctx, sendParams("other", "hello"))
+	if err != nil || task.Status.State != protocol.TaskStateCompleted {
+		t.Fatalf("SendTask of another task = %v, %v, want completed", task, err)
+	}
+
+	task, err = m.SendTask(ctx, sendParams("waiting", "more"))
+	if err != nil || task.Status.State != protocol.TaskStateCompleted {
+		t.Fatalf("follow-up SendTask = %v, %v, want completed", task, err)
+	}
+}